@@ -0,0 +1,203 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/gdamore/tcell"
+	"github.com/jwdevantier/spellbook/ui/keys"
+	"github.com/jwdevantier/spellbook/ui/lines"
+	"github.com/jwdevantier/spellbook/ui/statusbar"
+	table2 "github.com/jwdevantier/spellbook/ui/table"
+	"github.com/rivo/tview"
+	"github.com/spf13/cobra"
+)
+
+// exit codes of pick, as those of fzf
+const (
+	pickExitNoMatch   = 1
+	pickExitCancelled = 130
+)
+
+func init() {
+	rootCmd.AddCommand(pickCmd)
+	pickCmd.Flags().StringVarP(&pickDelimiter, "delimiter", "d", "",
+		"split lines into columns at this delimiter, e.g. '\\t' for TSV")
+	pickCmd.Flags().StringVar(&pickPrompt, "prompt", "> ", "text shown before the search")
+	pickCmd.Flags().BoolVarP(&pickMulti, "multi", "m", false,
+		"mark several lines with the toggle-mark key, all are printed")
+}
+
+// pickDelimiter splits the lines picked from into columns, pickPrompt is shown before the search
+var pickDelimiter string
+var pickPrompt string
+
+// pickMulti allows picking several lines
+var pickMulti bool
+
+// pickKeyHints are the key hints shown while picking a line, pickMultiKeyHints with --multi
+var (
+	pickKeyHints = []keyHint{
+		{[]keys.Action{keys.ActionAccept}, "pick"},
+		{[]keys.Action{keys.ActionCancel}, "cancel"},
+	}
+	pickMultiKeyHints = []keyHint{
+		{[]keys.Action{keys.ActionToggleMark}, "mark"},
+		{[]keys.Action{keys.ActionAccept}, "pick"},
+		{[]keys.Action{keys.ActionCancel}, "cancel"},
+	}
+)
+
+var pickCmd = &cobra.Command{
+	Use:   "pick [query]",
+	Short: "Pick a line read from stdin and print it",
+	Long: `Pick a line read from stdin and print it to stdout, e.g. in scripts.
+
+The picker is drawn on the terminal, not on stdout. The exit code is 0 once a
+line is picked, 1 if no line matches and 130 if picking is cancelled.`,
+	Args: cobra.ArbitraryArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		runPick(strings.Join(args, " "))
+	},
+}
+
+// runPick shows the lines read from stdin, filtered by query, printing the
+// line picked, and exits.
+func runPick(query string) {
+	if info, err := os.Stdin.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+		fmt.Fprintln(os.Stderr, "pick reads the lines to pick from stdin, e.g. `ls | spellbook pick`")
+		os.Exit(2)
+	}
+	read, err := lines.ReadLines(os.Stdin)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to read stdin: %v\n", err)
+		os.Exit(2)
+	}
+	delimiter := strings.NewReplacer(`\t`, "\t").Replace(pickDelimiter)
+	rows := lines.ToRows(read, delimiter)
+
+	if err := loadStyle(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load theme: %v\n", err)
+		os.Exit(2)
+	}
+	keymap, err := loadKeymap()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid keys: %v\n", err)
+		os.Exit(2)
+	}
+	fuzzy := lines.NewLineFuzzyFilter()
+	fuzzy.SetSearchString(query)
+	if selectOne || exitZero {
+		matched := fuzzy.Filter(rows)
+		if len(matched) == 0 && exitZero {
+			os.Exit(pickExitNoMatch)
+		}
+		if len(matched) == 1 && selectOne {
+			fmt.Println(matched[0].(*lines.LineRow).Text())
+			os.Exit(0)
+		}
+	}
+
+	screen, inlined := newScreen()
+	app := tview.NewApplication().SetScreen(screen).EnableMouse(true)
+
+	table := table2.NewTable(table2.NewTableModel(rows), lines.NewLineRenderer(rows))
+	table.Style(STYLE)
+	table.SetFilter(fuzzy)
+	table.SetWrapAround(wrapAround())
+	table.SetMarkable(pickMulti)
+
+	hints := pickKeyHints
+	if pickMulti {
+		hints = pickMultiKeyHints
+	}
+	status := statusbar.NewStatusBar()
+	status.Style(STYLE)
+	status.SetSources([]string{"stdin"}).SetHints(formatHints(keymap, hints))
+	table.SetOnRendered(func(matched, total int) {
+		status.SetCounts(matched, total)
+	})
+
+	inputField := NewInputField()
+	inputField.SetLabel(pickPrompt).SetPlaceholder("type to search")
+	inputField.Style(STYLE)
+	inputField.SetChangedFunc(func(text string) {
+		fuzzy.SetSearchString(text)
+		table.Render()
+	})
+	inputField.SetText(query)
+	table.Render()
+
+	exitCode := pickExitCancelled
+	var picked []string
+	// pick picks the marked lines or, if none are marked, the selected line
+	pick := func() {
+		for _, row := range table.GetSelectedRows() {
+			picked = append(picked, row.(*lines.LineRow).Text())
+		}
+		if len(picked) == 0 {
+			return
+		}
+		exitCode = 0
+		app.Stop()
+	}
+	table.SetOnDoubleClick(pick)
+	inputField.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		action := keymap.Action(event)
+		// keys which type a character only act on an empty search
+		if keys.KeyOf(event).Printable() && inputField.GetText() != "" {
+			action = keys.ActionNone
+		}
+		switch action {
+		case keys.ActionSelectUp:
+			table.SelectionUp()
+		case keys.ActionSelectDown:
+			table.SelectionDown()
+		case keys.ActionPageUp:
+			table.SelectionPageUp()
+		case keys.ActionPageDown:
+			table.SelectionPageDown()
+		case keys.ActionSelectFirst:
+			table.SelectionFirst()
+		case keys.ActionSelectLast:
+			table.SelectionLast()
+		case keys.ActionToggleMark:
+			if !pickMulti {
+				return event
+			}
+			table.ToggleMark()
+			status.SetMarked(table.MarkCount())
+		case keys.ActionAccept:
+			if len(table.GetSelectedRows()) == 0 {
+				exitCode = pickExitNoMatch
+				app.Stop()
+				return nil
+			}
+			pick()
+		case keys.ActionCancel:
+			app.Stop()
+		default:
+			return event
+		}
+		return nil
+	})
+
+	rootGrid := tview.NewGrid().
+		SetRows(1, -1, 1).
+		SetColumns(0).
+		SetBorders(!inlined)
+	STYLE.StyleGrid(rootGrid)
+	rootGrid.AddItem(status, 0, 0, 1, 1, 0, 0, false)
+	rootGrid.AddItem(table.Primitive(), 1, 0, 1, 1, 0, 0, false)
+	rootGrid.AddItem(inputField, 2, 0, 1, 1, 0, 0, true)
+
+	if err := app.SetRoot(rootGrid, true).SetFocus(inputField).Run(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	for _, line := range picked {
+		fmt.Println(line)
+	}
+	os.Exit(exitCode)
+}
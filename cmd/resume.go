@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/jwdevantier/spellbook/utils"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(resumeCmd)
+}
+
+var resumeCmd = &cobra.Command{
+	Use:   "resume",
+	Short: "Resume the last unfinished runbook checklist",
+	Run: func(cmd *cobra.Command, args []string) {
+		runbook, err := utils.LoadRunbook()
+		if os.IsNotExist(err) {
+			fmt.Println("no runbook to resume")
+			return
+		} else if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		runUI(runbook, "")
+	},
+}
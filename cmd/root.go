@@ -1,7 +1,8 @@
 package cmd
 
 import (
-	"fmt"
+	"errors"
+	"github.com/jwdevantier/spellbook/ui/inline"
 	"github.com/jwdevantier/spellbook/utils"
 	"github.com/mitchellh/go-homedir"
 	"github.com/spf13/cobra"
@@ -10,27 +11,65 @@ const configName = ".spellbook"
 
 func init() {
 	cobra.OnInitialize(initConfig)
+	rootCmd.PersistentFlags().BoolVar(&execMode, "exec", false,
+		"replace spellbook with the selected command instead of running it as a child process")
+	rootCmd.PersistentFlags().StringVar(&themeName, "theme", "",
+		"built-in theme name or path to a base16 scheme file (overrides `theme:` in the config)")
+	rootCmd.PersistentFlags().StringVar(&heightFlag, "height", "",
+		"show the picker below the prompt, this many rows (e.g. 15) or percent of the terminal (e.g. 40%) high")
+	rootCmd.PersistentFlags().BoolVar(&inlineMode, "inline", false,
+		"show the picker below the prompt instead of taking over the terminal, same as --height "+inline.DefaultHeight.String())
+	rootCmd.PersistentFlags().BoolVar(&selectOne, "select-1", false,
+		"pick the command straight away if it is the only one matching the query, running it if it has no variables")
+	rootCmd.PersistentFlags().BoolVar(&exitZero, "exit-0", false,
+		"exit with exit code 1, without showing the picker, if no command matches the query")
 }
 
+// execMode replaces the spellbook process with the selected command
+var execMode bool
+
+// themeName selects the UI theme, overriding the config
+var themeName string
+
+// heightFlag and inlineMode show the picker below the prompt rather than full screen
+var heightFlag string
+var inlineMode bool
+
+// selectOne picks the only command matching the query, exitZero exits if there are none
+var selectOne bool
+var exitZero bool
+
 var Config *utils.Config
 
+// configErr is why Config could not be read, reported by the commands needing it
+var configErr error
+
 func initConfig() {
 	home, err := homedir.Dir()
 	if err != nil {
+		configErr = err
 		return
 	}
-	config, err := utils.ReadConfig([]string{home, "."})
+	configDirs := []string{home}
+	if projectDir, found := utils.FindProjectDir("."); found && projectDir != home {
+		configDirs = append(configDirs, projectDir)
+	}
+	config, err := utils.ReadConfig(configDirs)
+	if errors.Is(err, utils.ErrNoConfig) {
+		// commands may still come from other sources, e.g. a Makefile
+		config, err = &utils.Config{}, nil
+	}
 	if err != nil {
-		fmt.Println("failed to read configs")
-		fmt.Println(err)
-		// TODO: abort, failed to read config
+		configErr = err
+		return
 	}
 	Config = config
 }
 
 var rootCmd = &cobra.Command{
-	Use: "spellbook",
+	Use: "spellbook [query]",
 	Short: "Easy access to your best shell commands",
+	Args: cobra.ArbitraryArgs,
 	Run: uiCmd.Run,
 }
 
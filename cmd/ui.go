@@ -4,53 +4,44 @@ import (
 	"fmt"
 	"github.com/gdamore/tcell"
 	"github.com/jwdevantier/spellbook/ui"
+	"github.com/jwdevantier/spellbook/ui/checklist"
+	"github.com/jwdevantier/spellbook/ui/confirm"
+	"github.com/jwdevantier/spellbook/ui/help"
+	"github.com/jwdevantier/spellbook/ui/inline"
 	"github.com/jwdevantier/spellbook/ui/inputfield"
+	"github.com/jwdevantier/spellbook/ui/keys"
+	"github.com/jwdevantier/spellbook/ui/output"
+	"github.com/jwdevantier/spellbook/ui/preview"
+	"github.com/jwdevantier/spellbook/ui/statusbar"
 	"github.com/jwdevantier/spellbook/ui/suggestions"
 	table2 "github.com/jwdevantier/spellbook/ui/table"
 	"github.com/jwdevantier/spellbook/utils"
 	"github.com/rivo/tview"
 	"github.com/spf13/cobra"
 	"os"
+	"os/exec"
+	"sort"
+	"strings"
 )
 
-var STYLE = &ui.Base16Theme{
-	Background: tcell.ColorDarkSlateGrey,
-	Foreground: tcell.ColorLightGray,
+var STYLE = ui.DefaultTheme
 
-	Black: tcell.ColorDarkSlateGrey,
-	BrightBlack: tcell.ColorSlateGray,
-
-	Red: tcell.ColorRed,
-	Green: tcell.ColorGreen,
-	Yellow: tcell.ColorYellow,
-	Blue: tcell.ColorBlue,
-	Magenta: tcell.ColorDarkMagenta,
-	Cyan: tcell.ColorDarkCyan,
-	White: tcell.ColorLightGray,
-
-	BrightRed: tcell.ColorOrangeRed,
-	BrightGreen: tcell.ColorGreenYellow,
-	BrightYellow: tcell.ColorLightYellow,
-	BrightBlue: tcell.ColorLightBlue,
-	BrightMagenta: tcell.ColorHotPink,
-	BrightCyan: tcell.ColorLightCyan,
-	BrightWhite: tcell.ColorWhite,
-}
-
-func initStyle() {
-	// go to def on tcell.ColorWhite
-	// first 16 colors match theme in e.g. iterm2
-	// ContrastBackgroundColor is used for input fields
-	// ContrastSecondaryTextColor - placeholder text for input fields
-	// PrimitiveBackgroundColor - general bg, not used for grid border cells, though
-
-	tview.Styles.PrimitiveBackgroundColor = 0 // 0:black
+// loadStyle sets STYLE to the theme selected by --theme or the config.
+func loadStyle() error {
+	name, dir := themeName, ""
+	if name == "" && Config != nil {
+		name, dir = Config.Theme, Config.ThemeDir
+	}
+	theme, err := ui.LoadTheme(name, dir)
+	if err != nil {
+		return err
+	}
+	STYLE = theme
+	return nil
 }
 
 func init() {
 	rootCmd.AddCommand(uiCmd)
-
-	initStyle()
 }
 
 // TODO: investigate tview Theme structure
@@ -65,108 +56,966 @@ func NewInputField() *inputfield.CompletionInputField {
 	return inputField
 }
 
-func NewTextView(label string) *tview.TextView {
-	text := tview.NewTextView().
-		SetTextAlign(tview.AlignCenter).
-		SetText(label)
-	STYLE.StyleTextView(text)
-	return text
+// workDirLabel describes the directory a command will be run in.
+func workDirLabel(command *utils.Command) string {
+	dir, err := command.WorkDir()
+	if err != nil {
+		return fmt.Sprintf("cwd: %s", err)
+	}
+	if dir == "" {
+		if dir, err = os.Getwd(); err != nil {
+			return fmt.Sprintf("cwd: %s", err)
+		}
+	}
+	return fmt.Sprintf("cwd: %s", dir)
+}
+
+// keyHint describes what the keys bound to actions do
+type keyHint struct {
+	actions []keys.Action
+	label   string
+}
+
+// key hints shown in the status bar for each mode
+var (
+	searchKeyHints = []keyHint{
+		{[]keys.Action{keys.ActionComplete, keys.ActionAccept}, "pick"},
+		{[]keys.Action{keys.ActionRunInline}, "run inline"},
+		{[]keys.Action{keys.ActionToggleMark}, "mark"},
+		{[]keys.Action{keys.ActionTogglePreview}, "preview"},
+		{[]keys.Action{keys.ActionToggleHelpOverlay}, "help"},
+		{[]keys.Action{keys.ActionCancel}, "quit"},
+	}
+	completionKeyHints = []keyHint{
+		{[]keys.Action{keys.ActionComplete}, "next"},
+		{[]keys.Action{keys.ActionPrevVariable}, "previous"},
+		{[]keys.Action{keys.ActionAccept}, "run"},
+		{[]keys.Action{keys.ActionCopy}, "copy"},
+		{[]keys.Action{keys.ActionCancel}, "back"},
+		{[]keys.Action{keys.ActionToggleHelpOverlay}, "help"},
+	}
+	checklistKeyHints = []keyHint{
+		{[]keys.Action{keys.ActionAccept}, "run step"},
+		{[]keys.Action{keys.ActionCancel}, "back"},
+		{[]keys.Action{keys.ActionToggleHelpOverlay}, "help"},
+	}
+)
+
+// formatHints describes hints with the first key bound to each action,
+// e.g. "Tab/Enter: pick  Esc: quit". Hints for actions without keys are left out.
+func formatHints(keymap *keys.Keymap, hints []keyHint) string {
+	parts := make([]string, 0, len(hints))
+	for _, hint := range hints {
+		names := make([]string, 0, len(hint.actions))
+		for _, action := range hint.actions {
+			if bound := keymap.Keys(action); len(bound) != 0 {
+				names = append(names, bound[0].String())
+			}
+		}
+		if len(names) != 0 {
+			parts = append(parts, strings.Join(names, "/")+": "+hint.label)
+		}
+	}
+	return strings.Join(parts, "  ")
+}
+
+// loadKeymap returns the key bindings set by `keymap:` and `keys:` in the
+// config, the default ones if the config could not be read.
+func loadKeymap() (*keys.Keymap, error) {
+	if Config == nil {
+		return keys.NewKeymap(keys.DefaultPreset, nil)
+	}
+	bindings := make(keys.Bindings, len(Config.Keys))
+	for action, names := range Config.Keys {
+		bindings[keys.Action(action)] = names
+	}
+	return keys.NewKeymap(Config.Keymap, bindings)
+}
+
+// wrapAround reports whether the selection wraps around the ends of the table.
+func wrapAround() bool {
+	return Config != nil && Config.WrapAround != nil && *Config.WrapAround
+}
+
+// sourceNames returns the names of sources, each once, e.g. "global" and "project".
+func sourceNames(sources []utils.CommandSource) []string {
+	names := make([]string, 0, len(sources))
+	seen := make(map[string]bool)
+	for _, source := range sources {
+		if !seen[source.Name()] {
+			seen[source.Name()] = true
+			names = append(names, source.Name())
+		}
+	}
+	return names
+}
+
+// confirmReason reports whether cmds must be confirmed before they are run, and why.
+func confirmReason(command *utils.Command, cmds []string) (string, bool) {
+	if command.Confirm {
+		return "This command must be confirmed before it is run:", true
+	}
+	for _, cmd := range cmds {
+		if utils.IsDangerous(cmd) {
+			return "This command looks dangerous:", true
+		}
+	}
+	return "", false
+}
+
+// resolveCommand expands command, its variables filled in from values, into
+// the commands to run, as written and with environment variables resolved.
+// Multi-step commands return one command per step.
+func resolveCommand(command *utils.Command, values map[string]string) ([]string, []string, error) {
+	rawCmds, err := command.ExpandSteps(values)
+	if err != nil {
+		return nil, nil, err
+	}
+	return resolveEnvVars(rawCmds)
+}
+
+// resolveCompleted is resolveCommand for the command completed in inputField.
+// A single command is run as shown, as a variable used more than once is
+// given a value in each place, but values keeps only the last one.
+func resolveCompleted(command *utils.Command, inputField *inputfield.CompletionInputField) ([]string, []string, error) {
+	if len(command.Steps) != 0 {
+		return resolveCommand(command, inputField.Values())
+	}
+	return resolveEnvVars([]string{inputField.GetText()})
+}
+
+// resolveEnvVars returns rawCmds along with rawCmds with environment
+// variables resolved.
+func resolveEnvVars(rawCmds []string) ([]string, []string, error) {
+	var err error
+	cmds := make([]string, len(rawCmds))
+	for i, rawCmd := range rawCmds {
+		if cmds[i], err = utils.ResolveEnvVars(rawCmd); err != nil {
+			return rawCmds, nil, fmt.Errorf("$ %s\n%v", rawCmd, err)
+		}
+	}
+	return rawCmds, cmds, nil
+}
+
+// runSteps runs each step of a multi-step command, returning the exit code
+// of the first step to fail.
+func runSteps(cmds []string, dir string, keepGoing bool) int {
+	results := utils.RunSteps(cmds, keepGoing, func(i int, cmd string) error {
+		fmt.Printf("[%d/%d] $ %s\n", i+1, len(cmds), cmd)
+		err := utils.Run(cmd, dir)
+		if _, ok := err.(*exec.ExitError); err != nil && !ok {
+			fmt.Println(err)
+		}
+		return err
+	})
+
+	fmt.Println()
+	for i, res := range results {
+		status := "skipped"
+		if !res.Skipped {
+			status = fmt.Sprintf("exit %d", utils.ExitCode(res.Err))
+		}
+		fmt.Printf("[%d/%d] %-8s %s\n", i+1, len(results), status, res.Cmd)
+	}
+	return utils.StepsExitCode(results)
+}
+
+// runBatch runs the commands of batch one after another, with their variables
+// filled in from values, then prints the exit code of each and exits with
+// the exit code of the first to fail.
+func runBatch(history *utils.History, batch *utils.Batch, values map[string]string) {
+	n := len(batch.Commands)
+	exitCodes := make([]int, n)
+	labels := make([]string, n)
+	for i, command := range batch.Commands {
+		labels[i] = command.Desc
+		if labels[i] == "" {
+			labels[i] = command.Text()
+		}
+		fmt.Printf("[%d/%d] %s\n", i+1, n, labels[i])
+		exitCodes[i] = runBatchCommand(command, values)
+		if err := recordRun(history, command, exitCodes[i]); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to record run: %v\n", err)
+		}
+		fmt.Println()
+	}
+	exitCode := 0
+	for i := range batch.Commands {
+		fmt.Printf("[%d/%d] exit %-3d %s\n", i+1, n, exitCodes[i], labels[i])
+		if exitCode == 0 {
+			exitCode = exitCodes[i]
+		}
+	}
+	os.Exit(exitCode)
+}
+
+// runBatchCommand runs a command of a batch, returning its exit code.
+func runBatchCommand(command *utils.Command, values map[string]string) int {
+	_, cmds, err := resolveCommand(command, values)
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+	dir, err := command.WorkDir()
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+	if dir != "" {
+		fmt.Println(workDirLabel(command))
+	}
+	results := utils.RunSteps(cmds, command.OnError == utils.OnErrorContinue, func(i int, cmd string) error {
+		fmt.Printf("$ %s\n", cmd)
+		err := utils.Run(cmd, dir)
+		if _, ok := err.(*exec.ExitError); err != nil && !ok {
+			fmt.Println(err)
+		}
+		return err
+	})
+	return utils.StepsExitCode(results)
+}
+
+// runbookLabel describes the runbook and the variable values used for its steps.
+func runbookLabel(rb *utils.Runbook) string {
+	names := make([]string, 0, len(rb.Values))
+	for name := range rb.Values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	values := make([]string, len(names))
+	for i, name := range names {
+		values[i] = fmt.Sprintf("%s=%s", name, rb.Values[name])
+	}
+	if len(values) == 0 {
+		return fmt.Sprintf("runbook: %s", rb.Title)
+	}
+	return fmt.Sprintf("runbook: %s (%s)", rb.Title, strings.Join(values, " "))
+}
+
+// pickerHeight returns the height of the picker if it is shown below the
+// prompt, as set by --height or --inline.
+func pickerHeight() (inline.Height, bool, error) {
+	if heightFlag != "" {
+		height, err := inline.ParseHeight(heightFlag)
+		return height, err == nil, err
+	}
+	return inline.DefaultHeight, inlineMode, nil
+}
+
+// newScreen initializes the screen the picker is drawn on, exiting if it
+// cannot, and adapts the theme to it. The screen is drawn below the prompt
+// if inlined is set.
+func newScreen() (screen tcell.Screen, inlined bool) {
+	height, inlined, err := pickerHeight()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if inlined {
+		screen, err = inline.NewScreen(height)
+	} else {
+		screen, err = tcell.NewScreen()
+	}
+	if err == nil {
+		err = screen.Init()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to initialize terminal: %v\n", err)
+		os.Exit(1)
+	}
+	// degrade the theme to what the terminal can show before any widget is styled
+	STYLE = STYLE.Degrade(ui.DetectColorDepth(screen.Colors()))
+	STYLE.StyleDefaults()
+	return screen, inlined
+}
+
+// recordRun records a run of command, which exited with exitCode, in the history.
+func recordRun(history *utils.History, command *utils.Command, exitCode int) error {
+	history.Record(command, exitCode)
+	return history.Save()
+}
+
+// runCommand runs cmds, the command(s) of command with its variables filled
+// in, and exits with their exit code.
+func runCommand(history *utils.History, command *utils.Command, cmds []string, dir string) {
+	if dir != "" {
+		fmt.Println(workDirLabel(command))
+	}
+	record := func(exitCode int) {
+		if err := recordRun(history, command, exitCode); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to record run: %v\n", err)
+		}
+	}
+	if len(command.Steps) != 0 {
+		exitCode := runSteps(cmds, dir, command.OnError == utils.OnErrorContinue)
+		record(exitCode)
+		os.Exit(exitCode)
+	}
+	cmd := cmds[0]
+	fmt.Printf("$ %s\n", cmd)
+	if execMode {
+		record(utils.ExitCodeUnknown)
+		err := utils.Exec(cmd, dir)
+		// only returns if the command could not be started
+		fmt.Println(err)
+		os.Exit(utils.ExitCode(err))
+	}
+	err := utils.Run(cmd, dir)
+	if _, ok := err.(*exec.ExitError); err != nil && !ok {
+		fmt.Println(err)
+	}
+	record(utils.ExitCode(err))
+	os.Exit(utils.ExitCode(err))
+}
+
+// runWithoutUI runs command without showing the picker and exits. It returns
+// if the command must be completed or run from the picker: if it has
+// variables, must be confirmed or is run inline or as a checklist.
+func runWithoutUI(history *utils.History, command *utils.Command) {
+	if command.Mode != "" {
+		return
+	}
+	if names, err := command.VarNames(); err != nil || len(names) != 0 {
+		return
+	}
+	rawCmds, err := command.ExpandSteps(nil)
+	if err != nil {
+		return
+	}
+	cmds := make([]string, len(rawCmds))
+	for i, rawCmd := range rawCmds {
+		if cmds[i], err = utils.ResolveEnvVars(rawCmd); err != nil {
+			fmt.Printf("$ %s\n", rawCmd)
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
+	if _, ok := confirmReason(command, cmds); ok {
+		return
+	}
+	dir, err := command.WorkDir()
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	runCommand(history, command, cmds, dir)
+}
+
+// saveRunbook saves the progress of the runbook, removing it once all steps are done.
+func saveRunbook(rb *utils.Runbook) error {
+	if rb.NextStep() == -1 {
+		return utils.RemoveRunbook()
+	}
+	return rb.Save()
 }
 
 var uiCmd = &cobra.Command{
 	Use: "ui",
 	Short: "testing go-prompt",
+	Args: cobra.ArbitraryArgs,
 	Run: func(cmd *cobra.Command, args []string) {
-		app := tview.NewApplication()
-
-		tableModel := table2.NewTableModel(suggestions.ToRowsCommands(Config.Commands))
-		renderer := suggestions.NewCommandRenderer()
-		table := table2.NewTable(tableModel, renderer)
-		table.Style(STYLE)
-		fuzzy := suggestions.NewCommandFuzzyFilter()
-		table.SetFilter(fuzzy)
-		table.SetOnSelected(func(cell *tview.TableCell) {
-			//cell.SetTextColor(tcell.ColorRebeccaPurple)
-		})
-		// TODO: move up to app-level if possible
-		table.SetOnEsc(func() {
-			app.Stop()
+		runUI(nil, strings.Join(args, " "))
+	},
+}
+
+// runUI runs the command picker, searching for query. If runbook is given,
+// the picker starts with the runbook's checklist shown.
+func runUI(runbook *utils.Runbook, query string) {
+	if Config == nil {
+		fmt.Fprintf(os.Stderr, "failed to read configs: %v\n", configErr)
+		os.Exit(1)
+	}
+	if err := loadStyle(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load theme: %v\n", err)
+		os.Exit(1)
+	}
+	renderer, err := suggestions.NewCommandRenderer(Config.Columns...)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid columns: %v\n", err)
+		os.Exit(1)
+	}
+	keymap, err := loadKeymap()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid keys: %v\n", err)
+		os.Exit(1)
+	}
+	searchHints := formatHints(keymap, searchKeyHints)
+	completionHints := formatHints(keymap, completionKeyHints)
+	checklistHints := formatHints(keymap, checklistKeyHints)
+	history, historyErr := utils.LoadHistory()
+	sources := utils.Sources(Config)
+	commands, sourceErrs := utils.LoadCommands(sources)
+	rows := suggestions.ToRowsCommands(commands, history)
+	fuzzy := suggestions.NewCommandFuzzyFilter()
+	fuzzy.SetSearchString(query)
+	// with --select-1, a command that is the only match is picked straight away
+	autoSelect := false
+	if runbook == nil && (selectOne || exitZero) {
+		matched := fuzzy.Filter(rows)
+		if len(matched) == 0 && exitZero {
+			os.Exit(1)
+		}
+		if len(matched) == 1 && selectOne {
+			runWithoutUI(history, matched[0].(*suggestions.CommandRow).Command())
+			autoSelect = true
+		}
+	}
+	screen, inlined := newScreen()
+	app := tview.NewApplication().SetScreen(screen).EnableMouse(true)
+
+	tableModel := table2.NewTableModel(rows)
+	table := table2.NewTable(tableModel, renderer)
+	table.Style(STYLE)
+	table.SetFilter(fuzzy)
+	table.SetWrapAround(wrapAround())
+	table.SetMarkable(true)
+	table.SetOnSelected(func(cell *tview.TableCell) {
+		//cell.SetTextColor(tcell.ColorRebeccaPurple)
+	})
+
+	rootGrid := tview.NewGrid().
+		SetRows(1, -1, 1). // height of each row
+		SetColumns(0).
+		// rows are scarce below the prompt, leave out the borders
+		SetBorders(!inlined)
+	STYLE.StyleGrid(rootGrid)
+
+	status := statusbar.NewStatusBar()
+	status.Style(STYLE)
+	status.SetSources(sourceNames(sources)).SetHints(searchHints)
+	rootGrid.AddItem(status, 0, 0, 1, 1, 0, 0, false)
+	table.SetOnRendered(func(matched, total int) {
+		status.SetCounts(matched, total)
+	})
+
+	if historyErr != nil {
+		status.SetMessage(historyErr.Error())
+	} else if len(sourceErrs) != 0 {
+		status.SetMessage(sourceErrs[0].Error())
+	}
+
+	// reload the commands as their sources change, e.g. as a config file is saved
+	done := make(chan struct{})
+	defer close(done)
+	reloadErr := ""
+	utils.WatchSources(sources, func(utils.CommandSource) {
+		commands, errs := utils.LoadCommands(sources)
+		rows := suggestions.ToRowsCommands(commands, history)
+		app.QueueUpdateDraw(func() {
+			tableModel.SetContents(rows)
+			if len(errs) != 0 {
+				reloadErr = errs[0].Error()
+				status.SetMessage(reloadErr)
+			} else if reloadErr != "" {
+				// the source is fixed, unless another message replaced the error
+				status.ClearMessage(reloadErr)
+				reloadErr = ""
+			}
 		})
+	}, done)
+	previewPane := preview.NewPreview(app)
+	previewPane.Style(STYLE)
+	updatePreview := func(row table2.Row) {
+		if row == nil {
+			previewPane.SetCommand(nil, nil)
+			return
+		}
+		command := row.(*suggestions.CommandRow).Command()
+		if last, found := history.Last(command); found {
+			previewPane.SetCommand(command, &last)
+		} else {
+			previewPane.SetCommand(command, nil)
+		}
+	}
+	table.SetOnSelectionChanged(updatePreview)
+	table.Render()
 
-		rootGrid := tview.NewGrid().
-			SetRows(1, -1, 1). // height of each row
-			SetColumns(0).
-			SetBorders(true)
-		STYLE.StyleGrid(rootGrid)
+	inputField := NewInputField()
+	inputField.Style(STYLE)
 
-		rootGrid.AddItem(NewTextView("Header"), 0, 0, 1, 1, 0, 0, false)
+	// command being completed, if any, and the batch of marked commands it
+	// is made of, if commands are marked
+	var selected *utils.Command
+	var batch *utils.Batch
+	enterCompletionMode := func() {
+		rows := table.GetSelectedRows()
+		if len(rows) == 0 {
+			return
+		}
+		context := ""
+		if table.MarkCount() == 0 {
+			batch = nil
+			selected = rows[0].(*suggestions.CommandRow).Command()
+			context = workDirLabel(selected)
+		} else {
+			batch = &utils.Batch{}
+			for _, row := range rows {
+				batch.Commands = append(batch.Commands, row.(*suggestions.CommandRow).Command())
+			}
+			selected = batch.Command()
+			context = fmt.Sprintf("running %s", selected.Desc)
+			for _, command := range batch.Commands {
+				if command.Mode != "" {
+					context += ", one after another outside the UI"
+					break
+				}
+			}
+		}
+		template, err := selected.Template()
+		if err != nil {
+			status.SetMessage(err.Error())
+			return
+		}
+		// TODO: handle error here..?
+		_ = inputField.EnterCompletionMode(template)
+		status.SetMode(statusbar.ModeCompletion).
+			SetContext(context).
+			SetHints(completionHints)
+	}
+	table.SetOnDoubleClick(enterCompletionMode)
+	// show the variable being completed, and its description, in the status bar
+	app.SetBeforeDrawFunc(func(screen tcell.Screen) bool {
+		name, _ := inputField.CurrentVar()
+		if status.Mode() == statusbar.ModeCompletion && name != status.Variable() {
+			status.SetVariable(name, selected.Vars[name])
+		}
+		return false
+	})
 
-		inputField := NewInputField()
-		inputField.Style(STYLE)
+	// stop the UI, allowing output to the terminal
+	stop := func() {
+		app.Stop()
+		// Required because of some bug in tcell when cleaning up the screen.
+		utils.PressEnterKey()
+	}
+	run := func(cmds []string, dir string) {
+		stop()
+		runCommand(history, selected, cmds, dir)
+	}
 
-		inputField.SetChangedFunc(func(text string) {
-			if !inputField.CompletionMode() {
-				fuzzy.SetSearchString(text)
-				table.Render()
+	outputPane := output.NewPane(app)
+	outputPane.Style(STYLE)
+	checklistView := checklist.NewChecklist()
+	checklistView.Style(STYLE)
+	// the commands or a runbook checklist, and the output pane below
+	// once a command has been run inline
+	views := tview.NewPages().
+		AddPage("table", table.Primitive(), true, true).
+		AddPage("checklist", checklistView, true, false)
+	middle := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(views, 0, 1, false)
+	// the middle and, if toggled, the preview pane
+	body := tview.NewFlex().AddItem(middle, 0, 1, false)
+	previewShown := false
+	togglePreview := func() {
+		if previewShown {
+			body.RemoveItem(previewPane)
+		} else {
+			// beside the commands if there is room, below them otherwise
+			if width, _ := screen.Size(); width >= 120 || inlined {
+				body.SetDirection(tview.FlexColumn)
+			} else {
+				body.SetDirection(tview.FlexRow)
 			}
+			body.AddItem(previewPane, 0, 1, false)
+		}
+		previewShown = !previewShown
+	}
+	outputShown := false
+	showOutput := func() {
+		if !outputShown {
+			middle.AddItem(outputPane, 0, 1, false)
+			outputShown = true
+		}
+	}
+	runInline := func(cmds []string, dir string) {
+		showOutput()
+		inputField.ExitCompletionMode()
+		command := selected
+		record := func(exitCode int) {
+			if err := recordRun(history, command, exitCode); err != nil {
+				status.SetMessage(err.Error())
+			}
+			table.Refresh()
+		}
+		if len(command.Steps) != 0 {
+			outputPane.RunSteps(cmds, dir, command.OnError == utils.OnErrorContinue, func(results []utils.StepResult) {
+				record(utils.StepsExitCode(results))
+			})
+			return
+		}
+		outputPane.Run(cmds[0], dir, func(err error) {
+			record(utils.ExitCode(err))
 		})
+	}
+
+	pages := tview.NewPages()
+	helpView := help.NewHelp(keymap)
+	helpView.Style(STYLE)
+	// the primitive focused before the help was shown, nil while it is hidden
+	var focusedBeforeHelp tview.Primitive
+	toggleHelp := func() {
+		if focusedBeforeHelp != nil {
+			pages.HidePage("help")
+			app.SetFocus(focusedBeforeHelp)
+			focusedBeforeHelp = nil
+			return
+		}
+		focusedBeforeHelp = app.GetFocus()
+		pages.ShowPage("help")
+		app.SetFocus(helpView)
+	}
+	helpView.SetOnClose(toggleHelp)
 
-		inputField.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
-			switch event.Key() {
-			case tcell.KeyUp:
-				if !inputField.CompletionMode() {
-					table.SelectionUp()
-					return nil
+	// actionOf returns the action bound to the key pressed in event. Keys
+	// which type a character only act where nothing is typed: on an empty
+	// search, or in the checklist.
+	actionOf := func(event *tcell.EventKey) keys.Action {
+		action := keymap.Action(event)
+		if action == keys.ActionNone || !keys.KeyOf(event).Printable() {
+			return action
+		}
+		switch app.GetFocus() {
+		case inputField:
+			if inputField.GetText() == "" && !inputField.CompletionMode() {
+				return action
+			}
+		case checklistView:
+			return action
+		}
+		return keys.ActionNone
+	}
+
+	app.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		// Ctrl-C stops a command run inline, rather than spellbook
+		if event.Key() == tcell.KeyCtrlC && outputPane.Running() {
+			outputPane.Cancel()
+			return nil
+		}
+		switch actionOf(event) {
+		case keys.ActionToggleHelpOverlay:
+			toggleHelp()
+			return nil
+		case keys.ActionTogglePreview:
+			togglePreview()
+			return nil
+		case keys.ActionToggleHelp:
+			previewPane.SetShowHelp(!previewPane.ShowHelp())
+			return nil
+		case keys.ActionScrollOutputUp:
+			outputPane.ScrollBy(-1)
+			return nil
+		case keys.ActionScrollOutputDown:
+			outputPane.ScrollBy(1)
+			return nil
+		}
+		return event
+	})
+
+	dialog := confirm.NewDialog()
+	dialog.Style(STYLE)
+	// askConfirm shows the confirmation dialog, calling onConfirm if the user confirms
+	askConfirm := func(reason string, cmds []string, dir string, subs []utils.EnvSubstitution, onConfirm func()) {
+		focused := app.GetFocus()
+		dialog.Prompt(reason, cmds, dir, subs)
+		dialog.SetOnConfirm(func() {
+			pages.HidePage("confirm")
+			app.SetFocus(focused)
+			onConfirm()
+		})
+		dialog.SetOnCancel(func() {
+			pages.HidePage("confirm")
+			app.SetFocus(focused)
+		})
+		pages.ShowPage("confirm")
+	}
+
+	openChecklist := func(rb *utils.Runbook) {
+		checklistView.SetRunbook(rb)
+		views.SwitchToPage("checklist")
+		status.SetMode(statusbar.ModeChecklist).
+			SetContext(runbookLabel(rb)).
+			SetHints(checklistHints)
+		app.SetFocus(checklistView)
+	}
+	checklistView.SetOnEsc(func() {
+		if checklistView.Running() != -1 {
+			return
+		}
+		views.SwitchToPage("table")
+		inputField.ExitCompletionMode()
+		app.SetFocus(inputField)
+	})
+	checklistView.SetOnChanged(func(i int) {
+		step := checklistView.Runbook().Steps[i]
+		if step.Ran() && !outputPane.Running() {
+			showOutput()
+			outputPane.Show(fmt.Sprintf("$ %s [exit %d]", step.Cmd, step.ExitCode), step.Output)
+		}
+	})
+	checklistView.SetOnRun(func(i int) {
+		rb := checklistView.Runbook()
+		cmd := rb.Steps[i].Cmd
+		runStep := func() {
+			showOutput()
+			checklistView.SetRunning(i)
+			outputPane.Run(cmd, rb.Dir, func(err error) {
+				rb.Record(i, err, outputPane.GetText(true))
+				checklistView.SetRunning(-1)
+				checklistView.SelectNext()
+				if err := saveRunbook(rb); err != nil {
+					status.SetMessage(err.Error())
+				} else if rb.NextStep() == -1 {
+					status.SetContext(fmt.Sprintf("runbook: %s - all steps done", rb.Title))
 				}
-			case tcell.KeyDown:
-				if !inputField.CompletionMode() {
-					table.SelectionDown()
-					return nil
+			})
+		}
+		if utils.IsDangerous(cmd) || rb.Confirm {
+			askConfirm("Run this step?", []string{cmd}, rb.Dir, nil, runStep)
+			return
+		}
+		runStep()
+	})
+
+	inputField.SetChangedFunc(func(text string) {
+		if !inputField.CompletionMode() {
+			if status.Mode() != statusbar.ModeSearch {
+				status.SetMode(statusbar.ModeSearch).SetHints(searchHints)
+			}
+			status.SetMessage("")
+			fuzzy.SetSearchString(text)
+			table.Render()
+		}
+	})
+
+	if query != "" {
+		inputField.SetText(query)
+	}
+
+	// accept runs the completed command, inline if runInlineKey is set or
+	// the command is to be run inline
+	accept := func(runInlineKey bool) {
+		rawCmds, cmds, err := resolveCompleted(selected, inputField)
+		if err != nil {
+			stop()
+			fmt.Println(err)
+			return
+		}
+		if batch != nil && runInlineKey {
+			status.SetMessage("marked commands cannot run inline, Enter runs them one after another")
+			return
+		}
+		if batch != nil {
+			// marked commands are run one after another, each in its own
+			// directory, leaving the UI whatever their mode
+			runCmds := func() {
+				stop()
+				runBatch(history, batch, inputField.Values())
+			}
+			if reason, ok := confirmReason(selected, cmds); ok {
+				subs := utils.EnvSubstitutions(strings.Join(rawCmds, "\n"))
+				askConfirm(reason, cmds, "", subs, runCmds)
+				return
+			}
+			runCmds()
+			return
+		}
+		dir, err := selected.WorkDir()
+		if err != nil {
+			stop()
+			fmt.Println(err)
+			return
+		}
+		if selected.Mode == utils.ModeChecklist {
+			newRunbook := func() {
+				rb := utils.NewRunbook(selected, cmds, inputField.Values(), dir)
+				openChecklist(rb)
+				if err := rb.Save(); err != nil {
+					status.SetMessage(err.Error())
 				}
-			case tcell.KeyTab:
-				row, found := table.GetSelectedRow()
-				if found {
-					// TODO: handle error here..?
-					_ = inputField.EnterCompletionMode(row.(*suggestions.CommandRow).Command().Cmd)
+			}
+			// only one runbook is saved, an unfinished one is resumed or replaced once confirmed
+			saved, err := utils.LoadRunbook()
+			if err != nil || saved.NextStep() == -1 {
+				newRunbook()
+			} else if saved.SameSteps(cmds, dir) {
+				openChecklist(saved)
+			} else {
+				steps := make([]string, len(saved.Steps))
+				for i, step := range saved.Steps {
+					steps[i] = step.Cmd
 				}
+				reason := fmt.Sprintf("Replace the unfinished runbook %s?", saved.Title)
+				askConfirm(reason, steps, saved.Dir, nil, newRunbook)
+			}
+			return
+		}
+		inlineRun := selected.Mode == utils.ModeInline || runInlineKey
+		if inlineRun && outputPane.Running() {
+			return
+		}
+		runCmd := func() {
+			if inlineRun {
+				runInline(cmds, dir)
+			} else {
+				run(cmds, dir)
+			}
+		}
+		if reason, ok := confirmReason(selected, cmds); ok {
+			subs := utils.EnvSubstitutions(strings.Join(rawCmds, "\n"))
+			askConfirm(reason, cmds, dir, subs, runCmd)
+			return
+		}
+		runCmd()
+	}
+
+	// copyCommand copies the completed command, with environment variables
+	// resolved, to the clipboard
+	copyCommand := func() {
+		_, cmds, err := resolveCompleted(selected, inputField)
+		if err != nil {
+			status.SetMessage(strings.ReplaceAll(err.Error(), "\n", ": "))
+			return
+		}
+		methods, err := utils.CopyToClipboard(strings.Join(cmds, "\n"))
+		if err != nil {
+			status.SetMessage(err.Error())
+			return
+		}
+		status.SetMessage(fmt.Sprintf("copied to the clipboard (%s)", strings.Join(methods, ", ")))
+	}
+
+	inputField.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch action := actionOf(event); action {
+		case keys.ActionSelectUp:
+			if !inputField.CompletionMode() {
+				table.SelectionUp()
 				return nil
-			case tcell.KeyEscape:
-				app.Stop()
+			}
+		case keys.ActionSelectDown:
+			if !inputField.CompletionMode() {
+				table.SelectionDown()
+				return nil
+			}
+		case keys.ActionPageUp:
+			if !inputField.CompletionMode() {
+				table.SelectionPageUp()
+				return nil
+			}
+		case keys.ActionPageDown:
+			if !inputField.CompletionMode() {
+				table.SelectionPageDown()
+				return nil
+			}
+		case keys.ActionSelectFirst:
+			if !inputField.CompletionMode() {
+				table.SelectionFirst()
+				return nil
+			}
+		case keys.ActionSelectLast:
+			if !inputField.CompletionMode() {
+				table.SelectionLast()
+				return nil
+			}
+		case keys.ActionToggleMark:
+			if !inputField.CompletionMode() {
+				table.ToggleMark()
+				status.SetMarked(table.MarkCount())
+				return nil
+			}
+		case keys.ActionComplete:
+			if inputField.CompletionMode() {
+				inputField.Complete()
+			} else {
+				enterCompletionMode()
+			}
+			return nil
+		case keys.ActionPrevVariable:
+			if inputField.CompletionMode() {
+				inputField.PrevVariable()
+				return nil
+			}
+		case keys.ActionNextVariable:
+			if inputField.CompletionMode() {
+				inputField.NextVariable()
 				return nil
-			case tcell.KeyEnter:
-				if !inputField.CompletionMode() {
-					// not in completion mode, enter it
-					row, found := table.GetSelectedRow()
-					if found {
-						// TODO: handle error here..?
-						_ = inputField.EnterCompletionMode(row.(*suggestions.CommandRow).Command().Cmd)
-					}
-					return nil
-				} else if inputField.CompletionDone() {
-					app.Stop()
-					// Required because of some bug in tcell when cleaning up the screen.
-					utils.PressEnterKey()
-
-					rawCmd := inputField.GetText()
-					cmd, err := utils.ResolveEnvVars(rawCmd)
-					if err != nil {
-						fmt.Printf("$ %s\n", rawCmd)
-						fmt.Println(err)
-						return nil
-					}
-					fmt.Printf("$ %s\n", cmd)
-					os.Exit(utils.ExitCode(utils.Run(cmd)))
-					return nil
+			}
+		case keys.ActionCopy:
+			if inputField.CompletionMode() {
+				if inputField.CompletionDone() {
+					copyCommand()
 				}
+				return nil
 			}
-			return event
-		})
-
-		rootGrid.AddItem(inputField, 2, 0, 1, 1, 0, 0, true)
-		rootGrid.AddItem(table.Primitive(), 1, 0, 1, 1, 0, 0, true)
+		case keys.ActionUndo:
+			if inputField.CompletionMode() {
+				inputField.Undo()
+				return nil
+			}
+		case keys.ActionRedo:
+			if inputField.CompletionMode() {
+				inputField.Redo()
+				return nil
+			}
+		case keys.ActionCancel:
+			if inputField.CompletionMode() {
+				inputField.ExitCompletionMode()
+			} else if table.MarkCount() != 0 {
+				table.ClearMarks()
+				status.SetMarked(0)
+			} else {
+				app.Stop()
+			}
+			return nil
+		case keys.ActionAccept, keys.ActionRunInline:
+			if !inputField.CompletionMode() {
+				// not in completion mode, enter it
+				enterCompletionMode()
+			} else if inputField.CompletionDone() {
+				accept(action == keys.ActionRunInline)
+			}
+			return nil
+		}
+		return event
+	})
 
-		if err := app.SetRoot(rootGrid, true).SetFocus(rootGrid).Run(); err != nil {
-			panic(err)
+	// the checklist is moved through with the keys bound to the actions, as well as its own keys
+	checklistView.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch actionOf(event) {
+		case keys.ActionSelectUp:
+			return tcell.NewEventKey(tcell.KeyUp, 0, tcell.ModNone)
+		case keys.ActionSelectDown:
+			return tcell.NewEventKey(tcell.KeyDown, 0, tcell.ModNone)
+		case keys.ActionPageUp:
+			return tcell.NewEventKey(tcell.KeyPgUp, 0, tcell.ModNone)
+		case keys.ActionPageDown:
+			return tcell.NewEventKey(tcell.KeyPgDn, 0, tcell.ModNone)
+		case keys.ActionSelectFirst:
+			return tcell.NewEventKey(tcell.KeyHome, 0, tcell.ModNone)
+		case keys.ActionSelectLast:
+			return tcell.NewEventKey(tcell.KeyEnd, 0, tcell.ModNone)
+		case keys.ActionAccept:
+			return tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone)
+		case keys.ActionCancel:
+			return tcell.NewEventKey(tcell.KeyEscape, 0, tcell.ModNone)
 		}
-	},
-}
\ No newline at end of file
+		return event
+	})
+
+	rootGrid.AddItem(inputField, 2, 0, 1, 1, 0, 0, true)
+	rootGrid.AddItem(body, 1, 0, 1, 1, 0, 0, true)
+
+	pages.AddPage("main", rootGrid, true, true)
+	pages.AddPage("confirm", dialog, true, false)
+	pages.AddPage("help", helpView, true, false)
+
+	app.SetRoot(pages, true).SetFocus(pages)
+	if runbook != nil {
+		openChecklist(runbook)
+	} else if autoSelect {
+		enterCompletionMode()
+	}
+	if err := app.Run(); err != nil {
+		panic(err)
+	}
+}
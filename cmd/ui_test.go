@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/gdamore/tcell"
+	"github.com/jwdevantier/spellbook/ui/inputfield"
+	"github.com/jwdevantier/spellbook/utils"
+)
+
+func TestResolveCompletedRepeatedVariable(t *testing.T) {
+	command := &utils.Command{Cmd: "cp %(f) %(f).bak"}
+	ci := inputfield.NewCompletionInputField()
+	ci.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey { return event })
+	handle := ci.InputHandler()
+	typeText := func(text string) {
+		for _, r := range text {
+			handle(tcell.NewEventKey(tcell.KeyRune, r, tcell.ModNone), nil)
+		}
+	}
+	if err := ci.EnterCompletionMode(command.Cmd); err != nil {
+		t.Fatal(err)
+	}
+	typeText("a.txt")
+	ci.Complete()
+	typeText("b")
+	ci.Complete()
+	if ci.GetText() != "cp a.txt b.bak" {
+		t.Fatalf("expected text 'cp a.txt b.bak', got '%s'", ci.GetText())
+	}
+
+	// the command is run as shown, each slot keeping its own value
+	_, cmds, err := resolveCompleted(command, ci)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cmds) != 1 || cmds[0] != "cp a.txt b.bak" {
+		t.Errorf("expected [cp a.txt b.bak], got %v", cmds)
+	}
+}
+
+func TestDefaultsWithoutConfig(t *testing.T) {
+	defer func(config *utils.Config) { Config = config }(Config)
+	Config = nil
+	if _, err := loadKeymap(); err != nil {
+		t.Errorf("expected the default keymap, got %v", err)
+	}
+	if err := loadStyle(); err != nil {
+		t.Errorf("expected the default theme, got %v", err)
+	}
+	if wrapAround() {
+		t.Error("expected no wraparound")
+	}
+}
@@ -3,12 +3,15 @@ module github.com/jwdevantier/spellbook
 go 1.14
 
 require (
+	github.com/creack/pty v1.1.11
 	github.com/gdamore/tcell v1.3.0
 	github.com/google/shlex v0.0.0-20191202100458-e7afc7fbc510
 	github.com/lithammer/fuzzysearch v1.1.0
+	github.com/mattn/go-runewidth v0.0.8
 	github.com/micmonay/keybd_event v1.1.0
 	github.com/mitchellh/go-homedir v1.1.0
 	github.com/rivo/tview v0.0.0-20200507165325-823f280c5426
+	github.com/rivo/uniseg v0.1.0
 	github.com/spf13/cobra v1.0.0
 	github.com/spf13/viper v1.7.0
 	gopkg.in/yaml.v2 v2.2.4
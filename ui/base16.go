@@ -0,0 +1,133 @@
+package ui
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"github.com/gdamore/tcell"
+	"gopkg.in/yaml.v2"
+)
+
+// Scheme is a base16 color scheme, as found in base16 scheme files:
+//
+//	scheme: "Default Dark"
+//	author: "Chris Kempson (http://chriskempson.com)"
+//	base00: "181818"
+//	...
+//	base0F: "a16946"
+//
+// Scheme files using the newer format, with the colors nested in a `palette`
+// section and `name` instead of `scheme`, are also supported.
+type Scheme struct {
+	Name   string
+	Author string
+	// Palette holds the hex colors base00-base0F
+	Palette [16]string
+}
+
+type SchemeError struct {
+	Scheme  string
+	Message string
+}
+
+func (e *SchemeError) Error() string {
+	return fmt.Sprintf("scheme '%s': %s", e.Scheme, e.Message)
+}
+
+type rawScheme struct {
+	Scheme  string            `yaml:"scheme"`
+	Name    string            `yaml:"name"`
+	Author  string            `yaml:"author"`
+	Palette map[string]string `yaml:"palette"`
+	Colors  map[string]string `yaml:",inline"`
+}
+
+// ParseScheme parses a base16 scheme file.
+func ParseScheme(data []byte) (*Scheme, error) {
+	var raw rawScheme
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	s := &Scheme{Name: raw.Scheme, Author: raw.Author}
+	if s.Name == "" {
+		s.Name = raw.Name
+	}
+	colors := raw.Colors
+	if len(raw.Palette) != 0 {
+		colors = raw.Palette
+	}
+	for key, val := range colors {
+		key = strings.ToLower(key)
+		if !strings.HasPrefix(key, "base0") || len(key) != len("base00") {
+			continue // e.g. 'variant' or another key we do not know
+		}
+		n, err := strconv.ParseUint(key[len("base0"):], 16, 8)
+		if err != nil {
+			continue
+		}
+		s.Palette[n] = val
+	}
+	for i, val := range s.Palette {
+		if val == "" {
+			return nil, &SchemeError{s.Name, fmt.Sprintf("base%02X is missing", i)}
+		}
+	}
+	return s, nil
+}
+
+// LoadScheme reads a base16 scheme file.
+func LoadScheme(path string) (*Scheme, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseScheme(data)
+}
+
+// Color returns base0N of the scheme.
+func (s *Scheme) Color(n int) (tcell.Color, error) {
+	hex := strings.TrimPrefix(s.Palette[n], "#")
+	v, err := strconv.ParseInt(hex, 16, 32)
+	if err != nil || len(hex) != 6 {
+		return tcell.ColorDefault, &SchemeError{s.Name, fmt.Sprintf("base%02X: invalid color '%s'", n, s.Palette[n])}
+	}
+	return tcell.NewHexColor(int32(v)), nil
+}
+
+// Theme assigns the colors of the scheme to the roles of a terminal palette,
+// following the mapping used by the base16 terminal templates.
+func (s *Scheme) Theme() (*Base16Theme, error) {
+	var c [16]tcell.Color
+	for i := range c {
+		var err error
+		if c[i], err = s.Color(i); err != nil {
+			return nil, err
+		}
+	}
+	return &Base16Theme{
+		Name: s.Name,
+
+		Background: c[0x00],
+		Foreground: c[0x05],
+
+		Black:   c[0x00],
+		Red:     c[0x08],
+		Green:   c[0x0B],
+		Yellow:  c[0x0A],
+		Blue:    c[0x0D],
+		Magenta: c[0x0E],
+		Cyan:    c[0x0C],
+		White:   c[0x05],
+
+		BrightBlack:   c[0x03],
+		BrightRed:     c[0x08],
+		BrightGreen:   c[0x0B],
+		BrightYellow:  c[0x0A],
+		BrightBlue:    c[0x0D],
+		BrightMagenta: c[0x0E],
+		BrightCyan:    c[0x0C],
+		BrightWhite:   c[0x07],
+	}, nil
+}
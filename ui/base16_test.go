@@ -0,0 +1,107 @@
+package ui
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gdamore/tcell"
+)
+
+const defaultDark = `
+scheme: "Default Dark"
+author: "Chris Kempson (http://chriskempson.com)"
+base00: "181818"
+base01: "282828"
+base02: "383838"
+base03: "585858"
+base04: "b8b8b8"
+base05: "d8d8d8"
+base06: "e8e8e8"
+base07: "f8f8f8"
+base08: "ab4642"
+base09: "dc9656"
+base0A: "f7ca88"
+base0B: "a1b56c"
+base0C: "86c1b9"
+base0D: "7cafc2"
+base0E: "ba8baf"
+base0F: "a16946"
+`
+
+func TestParseScheme(t *testing.T) {
+	scheme, err := ParseScheme([]byte(defaultDark))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if scheme.Name != "Default Dark" {
+		t.Errorf("expected name 'Default Dark', got '%s'", scheme.Name)
+	}
+	theme, err := scheme.Theme()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if theme.Background != tcell.NewHexColor(0x181818) {
+		t.Errorf("expected background to be base00")
+	}
+	if theme.Foreground != tcell.NewHexColor(0xd8d8d8) {
+		t.Errorf("expected foreground to be base05")
+	}
+	if theme.Yellow != tcell.NewHexColor(0xf7ca88) {
+		t.Errorf("expected yellow to be base0A")
+	}
+}
+
+func TestParseScheme_Palette(t *testing.T) {
+	data := "name: \"Nested\"\nvariant: \"dark\"\npalette:\n"
+	for _, key := range []string{"00", "01", "02", "03", "04", "05", "06", "07", "08", "09", "0A", "0B", "0C", "0D", "0E", "0F"} {
+		data += "  base" + key + ": \"#102030\"\n"
+	}
+	scheme, err := ParseScheme([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	theme, err := scheme.Theme()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if scheme.Name != "Nested" || theme.BrightWhite != tcell.NewHexColor(0x102030) {
+		t.Errorf("palette not parsed: %+v", scheme)
+	}
+}
+
+func TestParseScheme_Missing(t *testing.T) {
+	if _, err := ParseScheme([]byte("scheme: \"Broken\"\nbase00: \"000000\"\n")); err == nil {
+		t.Error("expected error for scheme missing colors")
+	}
+}
+
+func TestBuiltinThemes(t *testing.T) {
+	for _, name := range ThemeNames() {
+		if _, err := LoadTheme(name, ""); err != nil {
+			t.Errorf("theme '%s': %v", name, err)
+		}
+	}
+}
+
+func TestLoadTheme_RelativePath(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spellbook")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := os.Mkdir(filepath.Join(dir, "themes"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "themes", "dark.yaml"), []byte(defaultDark), 0644); err != nil {
+		t.Fatal(err)
+	}
+	theme, err := LoadTheme(filepath.Join("themes", "dark.yaml"), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if theme.Background != tcell.NewHexColor(0x181818) {
+		t.Errorf("expected the scheme in dir to be loaded")
+	}
+}
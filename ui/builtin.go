@@ -0,0 +1,108 @@
+package ui
+
+import (
+	"path/filepath"
+	"sort"
+
+	"github.com/gdamore/tcell"
+	"github.com/mitchellh/go-homedir"
+)
+
+// DefaultThemeName is the name of the theme used unless another is selected
+const DefaultThemeName = "default"
+
+// DefaultTheme is spellbook's original theme, using named colors.
+var DefaultTheme = &Base16Theme{
+	Name: DefaultThemeName,
+
+	Background: tcell.ColorDarkSlateGrey,
+	Foreground: tcell.ColorLightGray,
+
+	Black:       tcell.ColorDarkSlateGrey,
+	BrightBlack: tcell.ColorSlateGray,
+
+	Red:     tcell.ColorRed,
+	Green:   tcell.ColorGreen,
+	Yellow:  tcell.ColorYellow,
+	Blue:    tcell.ColorBlue,
+	Magenta: tcell.ColorDarkMagenta,
+	Cyan:    tcell.ColorDarkCyan,
+	White:   tcell.ColorLightGray,
+
+	BrightRed:     tcell.ColorOrangeRed,
+	BrightGreen:   tcell.ColorGreenYellow,
+	BrightYellow:  tcell.ColorLightYellow,
+	BrightBlue:    tcell.ColorLightBlue,
+	BrightMagenta: tcell.ColorHotPink,
+	BrightCyan:    tcell.ColorLightCyan,
+	BrightWhite:   tcell.ColorWhite,
+}
+
+// builtinSchemes are the base16 schemes shipped with spellbook
+var builtinSchemes = map[string]*Scheme{
+	"base16-default-dark": {
+		Name:   "Default Dark",
+		Author: "Chris Kempson (http://chriskempson.com)",
+		Palette: [16]string{
+			"181818", "282828", "383838", "585858", "b8b8b8", "d8d8d8", "e8e8e8", "f8f8f8",
+			"ab4642", "dc9656", "f7ca88", "a1b56c", "86c1b9", "7cafc2", "ba8baf", "a16946",
+		},
+	},
+	"solarized-dark": {
+		Name:   "Solarized Dark",
+		Author: "Ethan Schoonover (modified by aramisgithub)",
+		Palette: [16]string{
+			"002b36", "073642", "586e75", "657b83", "839496", "93a1a1", "eee8d5", "fdf6e3",
+			"dc322f", "cb4b16", "b58900", "859900", "2aa198", "268bd2", "6c71c4", "d33682",
+		},
+	},
+	"gruvbox-dark": {
+		Name:   "Gruvbox dark, medium",
+		Author: "Dawid Kurek (dawikur@gmail.com), morhetz (https://github.com/morhetz/gruvbox)",
+		Palette: [16]string{
+			"282828", "3c3836", "504945", "665c54", "bdae93", "d5c4a1", "ebdbb2", "fbf1c7",
+			"fb4934", "fe8019", "fabd2f", "b8bb26", "8ec07c", "83a598", "d3869b", "d65d0e",
+		},
+	},
+	"monokai": {
+		Name:   "Monokai",
+		Author: "Wimer Hazenberg (http://www.monokai.nl)",
+		Palette: [16]string{
+			"272822", "383830", "49483e", "75715e", "a59f85", "f8f8f2", "f5f4f1", "f9f8f5",
+			"f92672", "fd971f", "f4bf75", "a6e22e", "a1efe4", "66d9ef", "ae81ff", "cc6633",
+		},
+	},
+}
+
+// ThemeNames returns the names of the built-in themes.
+func ThemeNames() []string {
+	names := []string{DefaultThemeName}
+	for name := range builtinSchemes {
+		names = append(names, name)
+	}
+	sort.Strings(names[1:])
+	return names
+}
+
+// LoadTheme returns the built-in theme called name or, if there is none,
+// loads the base16 scheme file at path name, relative to dir.
+func LoadTheme(name, dir string) (*Base16Theme, error) {
+	if name == "" || name == DefaultThemeName {
+		return DefaultTheme, nil
+	}
+	if scheme, found := builtinSchemes[name]; found {
+		return scheme.Theme()
+	}
+	path, err := homedir.Expand(name)
+	if err != nil {
+		return nil, err
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	scheme, err := LoadScheme(path)
+	if err != nil {
+		return nil, err
+	}
+	return scheme.Theme()
+}
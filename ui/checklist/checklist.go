@@ -0,0 +1,141 @@
+package checklist
+
+import (
+	"fmt"
+
+	"github.com/gdamore/tcell"
+	"github.com/jwdevantier/spellbook/ui"
+	"github.com/jwdevantier/spellbook/utils"
+	"github.com/rivo/tview"
+)
+
+// Checklist shows the steps of a runbook, letting the user run them one at a time.
+type Checklist struct {
+	*tview.Table
+
+	runbook *utils.Runbook
+	// index of the step being run, -1 if none
+	running int
+
+	colorDone    tcell.Color
+	colorFailed  tcell.Color
+	colorRunning tcell.Color
+	colorText    tcell.Color
+
+	onRun     func(step int)
+	onEsc     func()
+	onChanged func(step int)
+}
+
+func NewChecklist() *Checklist {
+	c := &Checklist{
+		Table:   tview.NewTable().SetSelectable(true, false),
+		running: -1,
+
+		colorDone:    tcell.ColorGreen,
+		colorFailed:  tcell.ColorRed,
+		colorRunning: tcell.ColorYellow,
+		colorText:    tcell.ColorWhite,
+	}
+	c.Table.SetSelectedFunc(func(row, column int) {
+		if c.onRun != nil && c.running == -1 {
+			c.onRun(row)
+		}
+	})
+	c.Table.SetSelectionChangedFunc(func(row, column int) {
+		if c.onChanged != nil && c.runbook != nil && row >= 0 && row < len(c.runbook.Steps) {
+			c.onChanged(row)
+		}
+	})
+	c.Table.SetDoneFunc(func(key tcell.Key) {
+		if key == tcell.KeyEscape && c.onEsc != nil {
+			c.onEsc()
+		}
+	})
+	return c
+}
+
+// SetOnRun sets the handler called when the user asks to run a step.
+func (c *Checklist) SetOnRun(handler func(step int)) *Checklist {
+	c.onRun = handler
+	return c
+}
+
+// SetOnEsc sets the handler called when the user leaves the checklist.
+func (c *Checklist) SetOnEsc(handler func()) *Checklist {
+	c.onEsc = handler
+	return c
+}
+
+// SetOnChanged sets the handler called when another step is selected.
+func (c *Checklist) SetOnChanged(handler func(step int)) *Checklist {
+	c.onChanged = handler
+	return c
+}
+
+// Runbook returns the runbook shown, if any.
+func (c *Checklist) Runbook() *utils.Runbook {
+	return c.runbook
+}
+
+// SetRunbook shows the steps of runbook, selecting the first unfinished step.
+func (c *Checklist) SetRunbook(runbook *utils.Runbook) {
+	c.runbook = runbook
+	c.running = -1
+	c.Render()
+	c.SelectNext()
+}
+
+// SetRunning marks step as being run, -1 marks no step as running.
+func (c *Checklist) SetRunning(step int) {
+	c.running = step
+	c.Render()
+}
+
+// Running returns the index of the step being run, or -1.
+func (c *Checklist) Running() int {
+	return c.running
+}
+
+// SelectNext selects the first unfinished step, or the last step if all are done.
+func (c *Checklist) SelectNext() {
+	next := c.runbook.NextStep()
+	if next == -1 {
+		next = len(c.runbook.Steps) - 1
+	}
+	c.Select(next, 0)
+}
+
+func (c *Checklist) Render() {
+	c.Clear()
+	if c.runbook == nil {
+		return
+	}
+	for i, step := range c.runbook.Steps {
+		marker, status, color := "[ ]", "", c.colorText
+		switch {
+		case i == c.running:
+			marker, status, color = "[>]", "running", c.colorRunning
+		case step.Done:
+			marker, status, color = "[x]", step.RunAt.Format("15:04:05"), c.colorDone
+		case step.Ran():
+			marker, status, color = "[!]", fmt.Sprintf("exit %d", step.ExitCode), c.colorFailed
+		}
+		c.SetCell(i, 0, tview.NewTableCell(fmt.Sprintf("%d.", i+1)).
+			SetTextColor(c.colorText).SetAlign(tview.AlignRight))
+		c.SetCell(i, 1, tview.NewTableCell(tview.Escape(marker)).SetTextColor(color))
+		c.SetCell(i, 2, tview.NewTableCell(tview.Escape(step.Cmd)+"    ").SetTextColor(c.colorText).SetExpansion(1))
+		c.SetCell(i, 3, tview.NewTableCell(status).SetTextColor(color))
+	}
+}
+
+func (c *Checklist) Style(theme *ui.Base16Theme) {
+	c.SetBackgroundColor(theme.Background)
+	c.SetBordersColor(theme.Cyan)
+	c.colorText = theme.Foreground
+	c.colorDone = theme.Green
+	c.colorFailed = theme.Red
+	c.colorRunning = theme.Yellow
+	c.SetSelectedStyle(theme.SelectedStyle())
+	c.Render()
+}
@@ -0,0 +1,142 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/gdamore/tcell"
+)
+
+// ColorDepth is the number of colors a terminal can show.
+type ColorDepth int
+
+const (
+	Monochrome ColorDepth = iota
+	Colors16
+	Colors256
+	TrueColor
+)
+
+func (d ColorDepth) String() string {
+	switch d {
+	case Monochrome:
+		return "monochrome"
+	case Colors16:
+		return "16 colors"
+	case Colors256:
+		return "256 colors"
+	default:
+		return "truecolor"
+	}
+}
+
+// DetectColorDepth returns the color depth to use given the number of colors
+// reported by tcell, which already accounts for TERM, COLORTERM and
+// TCELL_TRUECOLOR. Setting NO_COLOR (see https://no-color.org) to any
+// non-empty value disables colors.
+func DetectColorDepth(colors int) ColorDepth {
+	if os.Getenv("NO_COLOR") != "" || strings.HasPrefix(os.Getenv("TERM"), "dumb") {
+		return Monochrome
+	}
+	switch {
+	case colors >= 1<<24:
+		return TrueColor
+	case colors >= 256:
+		return Colors256
+	case colors >= 8:
+		return Colors16
+	default:
+		return Monochrome
+	}
+}
+
+// palette256 holds the xterm 6x6x6 color cube and grayscale ramp. The first
+// 16 colors are left out as terminals let users redefine them.
+var palette256 = func() []tcell.Color {
+	palette := make([]tcell.Color, 0, 256-16)
+	for i := 16; i < 256; i++ {
+		palette = append(palette, tcell.Color(i))
+	}
+	return palette
+}()
+
+// Degrade returns a copy of the theme using only colors available at depth.
+//
+// With 256 colors, each color is replaced by the closest match in the xterm
+// palette. With 16 colors, each role is mapped to the matching ANSI color,
+// leaving the terminal's own palette to decide how it looks, and the
+// background and foreground are left to the terminal's defaults.
+func (t *Base16Theme) Degrade(depth ColorDepth) *Base16Theme {
+	degraded := *t
+	switch depth {
+	case TrueColor:
+	case Colors256:
+		degraded.mapColors(func(c tcell.Color) tcell.Color {
+			if c == tcell.ColorDefault || (c >= 0 && c < 16) {
+				return c // already a palette color
+			}
+			return tcell.FindColor(c, palette256)
+		})
+	case Colors16:
+		degraded = Base16Theme{
+			Name:       t.Name,
+			Background: tcell.ColorDefault,
+			Foreground: tcell.ColorDefault,
+
+			Black:   tcell.ColorBlack,
+			Red:     tcell.ColorMaroon,
+			Green:   tcell.ColorGreen,
+			Yellow:  tcell.ColorOlive,
+			Blue:    tcell.ColorNavy,
+			Magenta: tcell.ColorPurple,
+			Cyan:    tcell.ColorTeal,
+			White:   tcell.ColorSilver,
+
+			BrightBlack:   tcell.ColorGray,
+			BrightRed:     tcell.ColorRed,
+			BrightGreen:   tcell.ColorLime,
+			BrightYellow:  tcell.ColorYellow,
+			BrightBlue:    tcell.ColorBlue,
+			BrightMagenta: tcell.ColorFuchsia,
+			BrightCyan:    tcell.ColorAqua,
+			BrightWhite:   tcell.ColorWhite,
+		}
+	default:
+		degraded.mapColors(func(tcell.Color) tcell.Color {
+			return tcell.ColorDefault
+		})
+		degraded.Monochrome = true
+	}
+	return &degraded
+}
+
+func (t *Base16Theme) mapColors(fn func(tcell.Color) tcell.Color) {
+	for _, c := range []*tcell.Color{
+		&t.Background, &t.Foreground,
+		&t.Black, &t.Red, &t.Green, &t.Yellow, &t.Blue, &t.Magenta, &t.Cyan, &t.White,
+		&t.BrightBlack, &t.BrightRed, &t.BrightGreen, &t.BrightYellow,
+		&t.BrightBlue, &t.BrightMagenta, &t.BrightCyan, &t.BrightWhite,
+	} {
+		*c = fn(*c)
+	}
+}
+
+// ansiNames are the tview color tag names of the 16 ANSI colors
+var ansiNames = [16]string{
+	"black", "maroon", "green", "olive", "navy", "purple", "teal", "silver",
+	"gray", "red", "lime", "yellow", "blue", "fuchsia", "aqua", "white",
+}
+
+// ColorTag returns the name of c for use in tview color tags, such that
+// palette colors stay palette colors, e.g. "[" + ColorTag(c) + "]text".
+func ColorTag(c tcell.Color) string {
+	switch {
+	case c == tcell.ColorDefault:
+		return "-"
+	case c >= 0 && c < 16:
+		return ansiNames[c]
+	default:
+		return fmt.Sprintf("#%06x", c.Hex())
+	}
+}
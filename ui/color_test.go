@@ -0,0 +1,82 @@
+package ui
+
+import (
+	"os"
+	"testing"
+
+	"github.com/gdamore/tcell"
+)
+
+// setenv sets the environment variable key to value until the test is done
+func setenv(t *testing.T, key, value string) {
+	old, found := os.LookupEnv(key)
+	t.Cleanup(func() {
+		if found {
+			os.Setenv(key, old)
+		} else {
+			os.Unsetenv(key)
+		}
+	})
+	os.Setenv(key, value)
+}
+
+func TestDetectColorDepth(t *testing.T) {
+	setenv(t, "NO_COLOR", "")
+	setenv(t, "TERM", "xterm-256color")
+	for colors, expected := range map[int]ColorDepth{
+		1 << 24: TrueColor,
+		256:     Colors256,
+		88:      Colors16,
+		8:       Colors16,
+		0:       Monochrome,
+	} {
+		if depth := DetectColorDepth(colors); depth != expected {
+			t.Errorf("%d colors: expected %s, got %s", colors, expected, depth)
+		}
+	}
+
+	setenv(t, "NO_COLOR", "1")
+	if depth := DetectColorDepth(1 << 24); depth != Monochrome {
+		t.Errorf("expected NO_COLOR to disable colors, got %s", depth)
+	}
+}
+
+func TestDegrade(t *testing.T) {
+	theme, err := LoadTheme("gruvbox-dark", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if degraded := theme.Degrade(TrueColor); *degraded != *theme {
+		t.Error("expected truecolor theme to be unchanged")
+	}
+
+	degraded := theme.Degrade(Colors256)
+	if degraded.Red < 16 || degraded.Red > 255 {
+		t.Errorf("expected red to be a 256 color palette color, got %d", degraded.Red)
+	}
+
+	degraded = theme.Degrade(Colors16)
+	if degraded.BrightRed != tcell.ColorRed || degraded.Background != tcell.ColorDefault {
+		t.Errorf("expected ANSI colors, got %+v", degraded)
+	}
+
+	degraded = theme.Degrade(Monochrome)
+	if !degraded.Monochrome || degraded.Cyan != tcell.ColorDefault {
+		t.Errorf("expected monochrome theme, got %+v", degraded)
+	}
+	if _, _, attr := degraded.SelectedStyle(); attr != tcell.AttrReverse {
+		t.Error("expected monochrome selection to use reverse video")
+	}
+}
+
+func TestColorTag(t *testing.T) {
+	for _, c := range []tcell.Color{tcell.ColorMaroon, tcell.ColorAqua, tcell.Color(196), tcell.NewHexColor(0x102030)} {
+		if tagged := tcell.GetColor(ColorTag(c)); tagged.Hex() != c.Hex() {
+			t.Errorf("color %d: tag '%s' is color %d", c, ColorTag(c), tagged)
+		}
+	}
+	if tcell.GetColor(ColorTag(tcell.ColorRed)) != tcell.ColorRed {
+		t.Error("expected ANSI colors to be tagged by name")
+	}
+}
@@ -0,0 +1,123 @@
+package confirm
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gdamore/tcell"
+	"github.com/jwdevantier/spellbook/ui"
+	"github.com/jwdevantier/spellbook/utils"
+	"github.com/rivo/tview"
+)
+
+// Word is what the user must type to confirm running the command
+const Word = "yes"
+
+// Dialog is a modal asking the user to type Word before a command is run.
+type Dialog struct {
+	*tview.Flex
+
+	column  *tview.Flex
+	frame   *tview.Flex
+	details *tview.TextView
+	input   *tview.InputField
+
+	onConfirm func()
+	onCancel  func()
+}
+
+func NewDialog() *Dialog {
+	d := &Dialog{
+		details: tview.NewTextView().
+			SetDynamicColors(true).
+			SetWordWrap(true),
+		input: tview.NewInputField().
+			SetLabel("Confirm: ").
+			SetPlaceholder(fmt.Sprintf("type '%s' to run", Word)),
+	}
+	d.frame = tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(d.details, 0, 1, false).
+		AddItem(d.input, 1, 0, true)
+	d.frame.SetBorder(true).SetTitle(" Confirm command ")
+
+	// center the frame on the screen
+	d.column = tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(nil, 0, 1, false).
+		AddItem(d.frame, 0, 1, true).
+		AddItem(nil, 0, 1, false)
+	d.Flex = tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(d.column, 0, 6, true).
+		AddItem(nil, 0, 1, false)
+
+	d.input.SetDoneFunc(func(key tcell.Key) {
+		switch key {
+		case tcell.KeyEnter:
+			if d.input.GetText() == Word && d.onConfirm != nil {
+				d.onConfirm()
+			}
+		case tcell.KeyEscape:
+			if d.onCancel != nil {
+				d.onCancel()
+			}
+		}
+	})
+	return d
+}
+
+// SetOnConfirm sets the handler called once the user has typed Word.
+func (d *Dialog) SetOnConfirm(handler func()) *Dialog {
+	d.onConfirm = handler
+	return d
+}
+
+// SetOnCancel sets the handler called when the user presses Esc.
+func (d *Dialog) SetOnCancel(handler func()) *Dialog {
+	d.onCancel = handler
+	return d
+}
+
+// Prompt resets the dialog to ask for confirmation of cmds, which are run in dir.
+// subs are the environment variable substitutions made to cmds.
+func (d *Dialog) Prompt(reason string, cmds []string, dir string, subs []utils.EnvSubstitution) {
+	lines := []string{tview.Escape(reason), ""}
+	for _, cmd := range cmds {
+		lines = append(lines, "  $ "+tview.Escape(cmd))
+	}
+	if dir != "" {
+		lines = append(lines, "  cwd: "+tview.Escape(dir))
+	}
+	if len(subs) != 0 {
+		lines = append(lines, "", "Environment:")
+		for _, sub := range subs {
+			val := tview.Escape(sub.Value)
+			if !sub.Found {
+				val = "(undefined)"
+			}
+			lines = append(lines, fmt.Sprintf("  $%s = %s", sub.Name, val))
+		}
+	}
+	lines = append(lines, "", fmt.Sprintf("Type '%s' and press Enter to run, Esc to cancel.", Word))
+
+	d.details.SetText(strings.Join(lines, "\n")).ScrollToBeginning()
+	d.input.SetText("")
+	// details + border + input line + breathing room for wrapped lines
+	d.column.ResizeItem(d.frame, len(lines)+5, 0)
+}
+
+func (d *Dialog) Focus(delegate func(p tview.Primitive)) {
+	delegate(d.input)
+}
+
+func (d *Dialog) Style(theme *ui.Base16Theme) {
+	d.frame.SetBackgroundColor(theme.Background)
+	d.frame.SetBorderColor(theme.Red)
+	d.frame.SetTitleColor(theme.BrightRed)
+	d.details.SetBackgroundColor(theme.Background)
+	d.details.SetTextColor(theme.Foreground)
+	d.input.SetBackgroundColor(theme.Background)
+	d.input.SetLabelColor(theme.BrightRed)
+	d.input.SetFieldBackgroundColor(theme.BrightBlack)
+	d.input.SetFieldTextColor(theme.White)
+	d.input.SetPlaceholderTextColor(theme.BrightCyan)
+}
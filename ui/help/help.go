@@ -0,0 +1,198 @@
+package help
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gdamore/tcell"
+	"github.com/jwdevantier/spellbook/ui"
+	"github.com/jwdevantier/spellbook/ui/keys"
+	"github.com/rivo/tview"
+)
+
+// templateSyntax describes the syntax of command templates, as read by utils.ParseCmd
+var templateSyntax = [][2]string{
+	{"%(name)", "a variable, names use a-z, A-Z, - and _, and digits after the first character"},
+	{"%%", "a literal %"},
+	{"%x, %()", "kept as is, a % is only special before ( or %"},
+	{"$HOME, ${HOME}", "environment variables, filled in before the command is run"},
+}
+
+// editing describes how completing a command differs from editing text,
+// with the keys bound in keymap. Sentences about unbound actions are left out.
+func editing(keymap *keys.Keymap) []string {
+	prev := keyNames(keymap, keys.ActionPrevVariable)
+	next := keyNames(keymap, keys.ActionComplete, keys.ActionNextVariable)
+	undo := keyNames(keymap, keys.ActionUndo)
+	redo := keyNames(keymap, keys.ActionRedo)
+
+	lines := []string{
+		"The fixed parts of the command are inserted for you, up to the next variable.",
+		"Backspace deletes a fixed part as a whole, and a variable a character at a time.",
+	}
+	switch {
+	case prev != "" && next != "":
+		lines = append(lines, fmt.Sprintf("%s goes back to edit an earlier variable, %s moves on.", prev, next))
+	case prev != "":
+		lines = append(lines, fmt.Sprintf("%s goes back to edit an earlier variable.", prev))
+	}
+	lines = append(lines, "The fixed parts cannot be edited, the cursor stays within the variable.")
+	switch {
+	case undo != "" && redo != "":
+		lines = append(lines, fmt.Sprintf("%s undoes a change, including a deleted fixed part, %s redoes it.", undo, redo))
+	case undo != "":
+		lines = append(lines, fmt.Sprintf("%s undoes a change, including a deleted fixed part.", undo))
+	}
+	return append(lines, "Keys which type a character, like ?, only act on an empty search.")
+}
+
+// keyNames lists the keys bound to actions, e.g. "Shift-Tab or Alt-Left".
+func keyNames(keymap *keys.Keymap, actions ...keys.Action) string {
+	var names []string
+	for _, action := range actions {
+		for _, key := range keymap.Keys(action) {
+			names = append(names, key.String())
+		}
+	}
+	return strings.Join(names, " or ")
+}
+
+// Help is a modal listing the keys bound to each action, how commands are
+// completed and the syntax of command templates.
+type Help struct {
+	*tview.Flex
+
+	column *tview.Flex
+	text   *tview.TextView
+	keymap *keys.Keymap
+	// number of lines of text
+	lines int
+
+	colorHeading tcell.Color
+	colorKey     tcell.Color
+
+	onClose func()
+}
+
+func NewHelp(keymap *keys.Keymap) *Help {
+	h := &Help{
+		text: tview.NewTextView().
+			SetDynamicColors(true).
+			SetWordWrap(true).
+			SetScrollable(true),
+		keymap: keymap,
+
+		colorHeading: tcell.ColorDarkCyan,
+		colorKey:     tcell.ColorOrange,
+	}
+	h.text.SetBorder(true).SetTitle(" Help ")
+
+	// center the text on the screen
+	h.column = tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(nil, 0, 1, false).
+		AddItem(h.text, 0, 1, true).
+		AddItem(nil, 0, 1, false)
+	h.Flex = tview.NewFlex().
+		AddItem(nil, 0, 1, false).
+		AddItem(h.column, 0, 14, true).
+		AddItem(nil, 0, 1, false)
+
+	h.text.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		action := keymap.Action(event)
+		if action == keys.ActionToggleHelpOverlay || action == keys.ActionCancel ||
+			(event.Key() == tcell.KeyRune && event.Rune() == 'q') {
+			if h.onClose != nil {
+				h.onClose()
+			}
+			return nil
+		}
+		return event
+	})
+	h.Render()
+	return h
+}
+
+// SetOnClose sets the handler called when the user closes the help.
+func (h *Help) SetOnClose(handler func()) *Help {
+	h.onClose = handler
+	return h
+}
+
+func (h *Help) heading(text string) string {
+	return fmt.Sprintf("[%s::b]%s[-::-]", ui.ColorTag(h.colorHeading), text)
+}
+
+func (h *Help) key(text string, width int) string {
+	padding := strings.Repeat(" ", width-len(text))
+	return fmt.Sprintf("[%s]%s[-]%s", ui.ColorTag(h.colorKey), tview.Escape(text), padding)
+}
+
+// Render writes the help text for the key map.
+func (h *Help) Render() {
+	lines := []string{h.heading("Keys")}
+	bindings := make([][2]string, 0, len(keys.Actions))
+	for _, action := range keys.Actions {
+		bound := h.keymap.Keys(action)
+		if len(bound) == 0 {
+			continue
+		}
+		names := make([]string, len(bound))
+		for i, key := range bound {
+			names[i] = key.String()
+		}
+		bindings = append(bindings, [2]string{strings.Join(names, ", "), action.Description()})
+	}
+	lines = append(lines, h.table(bindings)...)
+
+	lines = append(lines, "", h.heading("Completing commands"))
+	for _, line := range editing(h.keymap) {
+		lines = append(lines, "  "+tview.Escape(line))
+	}
+
+	lines = append(lines, "", h.heading("Templates"))
+	lines = append(lines, h.table(templateSyntax)...)
+
+	h.text.SetText(strings.Join(lines, "\n")).ScrollToBeginning()
+	h.lines = len(lines)
+}
+
+// Draw fits the help to the screen, the text can be scrolled if it is too long.
+func (h *Help) Draw(screen tcell.Screen) {
+	_, _, _, height := h.GetRect()
+	// text + border + room for wrapped lines
+	size := h.lines + 4
+	if size > height {
+		size = height
+	}
+	h.column.ResizeItem(h.text, size, 0)
+	h.Flex.Draw(screen)
+}
+
+// table lays out rows of a key, or syntax, and its description in two columns.
+func (h *Help) table(rows [][2]string) []string {
+	width := 0
+	for _, row := range rows {
+		if len(row[0]) > width {
+			width = len(row[0])
+		}
+	}
+	lines := make([]string, len(rows))
+	for i, row := range rows {
+		lines[i] = "  " + h.key(row[0], width) + "  " + tview.Escape(row[1])
+	}
+	return lines
+}
+
+func (h *Help) Focus(delegate func(p tview.Primitive)) {
+	delegate(h.text)
+}
+
+func (h *Help) Style(theme *ui.Base16Theme) {
+	h.text.SetBackgroundColor(theme.Background)
+	h.text.SetTextColor(theme.Foreground)
+	h.text.SetBorderColor(theme.Cyan)
+	h.text.SetTitleColor(theme.BrightCyan)
+	h.colorHeading = theme.Cyan
+	h.colorKey = theme.BrightGreen
+	h.Render()
+}
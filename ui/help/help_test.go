@@ -0,0 +1,30 @@
+package help
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jwdevantier/spellbook/ui/keys"
+)
+
+func TestEditingUsesKeymap(t *testing.T) {
+	keymap, err := keys.NewKeymap(keys.DefaultPreset, keys.Bindings{
+		keys.ActionPrevVariable: {"ctrl-b"},
+		keys.ActionUndo:         {"alt-u"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := strings.Join(editing(keymap), "\n")
+	for _, expected := range []string{
+		"Ctrl-B goes back to edit an earlier variable, Tab or Alt-Right moves on.",
+		"Alt-U undoes a change, including a deleted fixed part, Ctrl-Y redoes it.",
+	} {
+		if !strings.Contains(text, expected) {
+			t.Errorf("expected '%s' in:\n%s", expected, text)
+		}
+	}
+	if strings.Contains(text, "Shift-Tab") || strings.Contains(text, "Ctrl-Z") {
+		t.Errorf("expected the default keys replaced, got:\n%s", text)
+	}
+}
@@ -0,0 +1,59 @@
+package inline
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// MinRows is the fewest rows the picker is given, room for the status bar,
+// the input field and a few commands.
+const MinRows = 6
+
+// Height is the height of the picker, either a number of rows or a
+// percentage of the terminal's rows.
+type Height struct {
+	Value   int
+	Percent bool
+}
+
+// DefaultHeight is the height used by --inline
+var DefaultHeight = Height{Value: 40, Percent: true}
+
+// ParseHeight parses heights like "40%" or "15".
+func ParseHeight(s string) (Height, error) {
+	h := Height{}
+	value := strings.TrimSpace(s)
+	if strings.HasSuffix(value, "%") {
+		h.Percent = true
+		value = strings.TrimSuffix(value, "%")
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 || (h.Percent && n > 100) {
+		return h, fmt.Errorf("invalid height '%s', expected rows (e.g. 15) or a percentage (e.g. 40%%)", s)
+	}
+	h.Value = n
+	return h, nil
+}
+
+// Rows returns the number of rows of a terminal with termRows rows to use.
+func (h Height) Rows(termRows int) int {
+	rows := h.Value
+	if h.Percent {
+		rows = termRows * h.Value / 100
+	}
+	if rows < MinRows {
+		rows = MinRows
+	}
+	if rows > termRows {
+		rows = termRows
+	}
+	return rows
+}
+
+func (h Height) String() string {
+	if h.Percent {
+		return fmt.Sprintf("%d%%", h.Value)
+	}
+	return strconv.Itoa(h.Value)
+}
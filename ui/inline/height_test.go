@@ -0,0 +1,48 @@
+package inline
+
+import "testing"
+
+func TestParseHeight(t *testing.T) {
+	cases := []struct {
+		value    string
+		expected Height
+	}{
+		{"40%", Height{40, true}},
+		{"15", Height{15, false}},
+		{" 100% ", Height{100, true}},
+	}
+	for _, c := range cases {
+		h, err := ParseHeight(c.value)
+		if err != nil {
+			t.Errorf("'%s': unexpected error %v", c.value, err)
+		} else if h != c.expected {
+			t.Errorf("'%s': expected %v, got %v", c.value, c.expected, h)
+		}
+	}
+
+	for _, value := range []string{"", "0", "-3", "150%", "ten", "%"} {
+		if _, err := ParseHeight(value); err == nil {
+			t.Errorf("'%s': expected an error", value)
+		}
+	}
+}
+
+func TestHeightRows(t *testing.T) {
+	cases := []struct {
+		height   Height
+		termRows int
+		expected int
+	}{
+		{Height{40, true}, 50, 20},
+		{Height{15, false}, 50, 15},
+		// never fewer than MinRows, nor more than the terminal has
+		{Height{10, true}, 30, MinRows},
+		{Height{80, false}, 24, 24},
+		{Height{2, false}, 4, 4},
+	}
+	for _, c := range cases {
+		if rows := c.height.Rows(c.termRows); rows != c.expected {
+			t.Errorf("%v of %d rows: expected %d, got %d", c.height, c.termRows, c.expected, rows)
+		}
+	}
+}
@@ -0,0 +1,384 @@
+// +build !windows
+
+package inline
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gdamore/tcell"
+	"github.com/gdamore/tcell/terminfo"
+)
+
+// Screen draws on the bottom rows of the terminal, below the shell prompt,
+// rather than taking over the whole terminal like tcell's screens.
+//
+// Input is read through a tcell screen whose terminfo entry is patched so
+// that it neither switches to the alternate screen nor clears the terminal.
+// Its drawing methods are never called, instead Screen keeps its own cells
+// and draws them on the rows it owns.
+type Screen struct {
+	tcell.Screen
+
+	ti     *terminfo.Terminfo
+	tty    *os.File
+	height Height
+
+	mu    sync.Mutex
+	cells tcell.CellBuffer
+	style tcell.Style
+	// terminal row of the first row of the screen
+	top           int
+	width, rows   int
+	cursorX       int
+	cursorY       int
+	cursorVisible bool
+
+	out       bytes.Buffer
+	curStyle  tcell.Style
+	truecolor bool
+	palette   []tcell.Color
+}
+
+// NewScreen returns a screen height rows high, drawn below the cursor.
+func NewScreen(height Height) (tcell.Screen, error) {
+	name := os.Getenv("TERM")
+	ti, err := terminfo.LookupTerminfo(name)
+	if err != nil {
+		// tcell's screen falls back to asking infocmp, adding what it learns
+		if _, err := tcell.NewTerminfoScreen(); err != nil {
+			return nil, err
+		}
+		if ti, err = terminfo.LookupTerminfo(name); err != nil {
+			return nil, err
+		}
+	}
+	patched := *ti
+	patched.Name = "spellbook-inline-" + ti.Name
+	patched.Aliases = nil
+	patched.EnterCA = ""
+	patched.ExitCA = ""
+	patched.Clear = ""
+	terminfo.AddTerminfo(&patched)
+
+	// tcell looks up the terminfo entry named by TERM
+	os.Setenv("TERM", patched.Name)
+	inner, err := tcell.NewTerminfoScreen()
+	os.Setenv("TERM", name)
+	if err != nil {
+		return nil, err
+	}
+	return &Screen{
+		Screen: inner,
+		ti:     &patched,
+		height: height,
+		style:  tcell.StyleDefault,
+	}, nil
+}
+
+func (s *Screen) Init() error {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	s.tty = tty
+	row, rowErr := cursorRow(tty)
+	if err := s.Screen.Init(); err != nil {
+		tty.Close()
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	width, termRows := s.Screen.Size()
+	if rowErr != nil {
+		// draw at the bottom of the terminal
+		row = termRows
+	}
+	s.truecolor = s.Screen.Colors() >= 1<<24
+	s.palette = make([]tcell.Color, 0, s.ti.Colors)
+	for i := 0; i < s.ti.Colors && i < 256; i++ {
+		s.palette = append(s.palette, tcell.Color(i))
+	}
+	s.curStyle = tcell.Style(-1)
+	s.cursorVisible = false
+	s.top = row - 1
+	s.layout(width, termRows)
+	s.flush()
+	return nil
+}
+
+// layout sizes the screen for a terminal of width x termRows, scrolling the
+// terminal up if there are not enough rows below the cursor.
+func (s *Screen) layout(width int, termRows int) {
+	s.width = width
+	s.rows = s.height.Rows(termRows)
+	if scroll := s.top + s.rows - termRows; scroll > 0 {
+		s.out.WriteString(s.ti.TGoto(0, termRows-1))
+		s.out.WriteString(strings.Repeat("\n", scroll))
+		s.top -= scroll
+	}
+	if s.top < 0 {
+		s.top = 0
+	}
+	s.cells.Resize(s.width, s.rows)
+	s.cells.Invalidate()
+	s.clearRows()
+}
+
+// clearRows blanks the rows of the screen on the terminal
+func (s *Screen) clearRows() {
+	s.out.WriteString(s.ti.AttrOff)
+	s.curStyle = tcell.Style(-1)
+	for y := 0; y < s.rows; y++ {
+		s.out.WriteString(s.ti.TGoto(0, s.top+y))
+		s.out.WriteString("\x1b[K")
+	}
+}
+
+func (s *Screen) flush() {
+	s.tty.Write(s.out.Bytes())
+	s.out.Reset()
+}
+
+// Fini clears the rows of the screen, leaving the cursor where the screen
+// started.
+func (s *Screen) Fini() {
+	s.mu.Lock()
+	s.clearRows()
+	s.out.WriteString(s.ti.TGoto(0, s.top))
+	s.out.WriteString(s.ti.ShowCursor)
+	s.flush()
+	s.tty.Close()
+	s.mu.Unlock()
+	s.Screen.Fini()
+}
+
+func (s *Screen) Clear() {
+	s.Fill(' ', s.style)
+}
+
+func (s *Screen) Fill(r rune, style tcell.Style) {
+	s.mu.Lock()
+	s.cells.Fill(r, style)
+	s.mu.Unlock()
+}
+
+func (s *Screen) SetCell(x int, y int, style tcell.Style, ch ...rune) {
+	if len(ch) == 0 {
+		s.SetContent(x, y, ' ', nil, style)
+		return
+	}
+	s.SetContent(x, y, ch[0], ch[1:], style)
+}
+
+func (s *Screen) GetContent(x, y int) (rune, []rune, tcell.Style, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cells.GetContent(x, y)
+}
+
+func (s *Screen) SetContent(x int, y int, mainc rune, combc []rune, style tcell.Style) {
+	s.mu.Lock()
+	s.cells.SetContent(x, y, mainc, combc, style)
+	s.mu.Unlock()
+}
+
+func (s *Screen) SetStyle(style tcell.Style) {
+	s.mu.Lock()
+	s.style = style
+	s.mu.Unlock()
+}
+
+func (s *Screen) ShowCursor(x int, y int) {
+	s.mu.Lock()
+	s.cursorX, s.cursorY, s.cursorVisible = x, y, true
+	s.mu.Unlock()
+}
+
+func (s *Screen) HideCursor() {
+	s.mu.Lock()
+	s.cursorVisible = false
+	s.mu.Unlock()
+}
+
+func (s *Screen) Size() (int, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.width, s.rows
+}
+
+// Resize is a no-op, as for tcell's screens
+func (s *Screen) Resize(int, int, int, int) {}
+
+// PollEvent returns the next event, with mouse events made relative to the
+// screen and events outside of it dropped.
+func (s *Screen) PollEvent() tcell.Event {
+	for {
+		ev := s.Screen.PollEvent()
+		switch ev := ev.(type) {
+		case *tcell.EventResize:
+			s.mu.Lock()
+			width, termRows := s.Screen.Size()
+			s.layout(width, termRows)
+			s.flush()
+			s.mu.Unlock()
+			return ev
+		case *tcell.EventMouse:
+			x, y := ev.Position()
+			s.mu.Lock()
+			y -= s.top
+			rows := s.rows
+			s.mu.Unlock()
+			if y < 0 || y >= rows {
+				continue
+			}
+			return tcell.NewEventMouse(x, y, ev.Buttons(), ev.Modifiers())
+		default:
+			return ev
+		}
+	}
+}
+
+// Show draws the cells which changed since they were last drawn.
+func (s *Screen) Show() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.draw()
+}
+
+// Sync redraws every cell.
+func (s *Screen) Sync() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cells.Invalidate()
+	s.clearRows()
+	s.draw()
+}
+
+func (s *Screen) draw() {
+	s.out.WriteString(s.ti.HideCursor)
+	for y := 0; y < s.rows; y++ {
+		for x := 0; x < s.width; x++ {
+			if !s.cells.Dirty(x, y) {
+				continue
+			}
+			mainc, combc, style, width := s.cells.GetContent(x, y)
+			if x+width > s.width {
+				// a wide character which does not fit
+				mainc, combc, width = ' ', nil, 1
+			}
+			s.out.WriteString(s.ti.TGoto(x, s.top+y))
+			s.sendStyle(style)
+			s.out.WriteRune(mainc)
+			for _, r := range combc {
+				s.out.WriteRune(r)
+			}
+			s.cells.SetDirty(x, y, false)
+			if width > 1 {
+				x++
+				s.cells.SetDirty(x, y, false)
+			}
+		}
+	}
+	if s.cursorVisible && s.cursorX >= 0 && s.cursorX < s.width && s.cursorY >= 0 && s.cursorY < s.rows {
+		s.out.WriteString(s.ti.TGoto(s.cursorX, s.top+s.cursorY))
+		s.out.WriteString(s.ti.ShowCursor)
+	}
+	s.flush()
+}
+
+func (s *Screen) sendStyle(style tcell.Style) {
+	if style == s.curStyle {
+		return
+	}
+	s.curStyle = style
+	fg, bg, attrs := style.Decompose()
+	s.out.WriteString(s.ti.AttrOff)
+	s.sendColor(fg, s.ti.SetFg, s.ti.SetFgRGB)
+	s.sendColor(bg, s.ti.SetBg, s.ti.SetBgRGB)
+	for _, a := range []struct {
+		mask tcell.AttrMask
+		seq  string
+	}{
+		{tcell.AttrBold, s.ti.Bold},
+		{tcell.AttrBlink, s.ti.Blink},
+		{tcell.AttrReverse, s.ti.Reverse},
+		{tcell.AttrUnderline, s.ti.Underline},
+		{tcell.AttrDim, s.ti.Dim},
+	} {
+		if attrs&a.mask != 0 {
+			s.out.WriteString(a.seq)
+		}
+	}
+}
+
+// sendColor sets a color, using the terminal's palette for palette colors
+// and the closest palette color for other colors unless the terminal
+// supports truecolor.
+func (s *Screen) sendColor(c tcell.Color, set string, setRGB string) {
+	if c == tcell.ColorDefault || len(s.palette) == 0 || set == "" {
+		return
+	}
+	if c >= 0 && int(c) < len(s.palette) {
+		s.out.WriteString(s.ti.TParm(set, int(c)))
+		return
+	}
+	if s.truecolor && setRGB != "" {
+		r, g, b := c.RGB()
+		s.out.WriteString(s.ti.TParm(setRGB, int(r), int(g), int(b)))
+		return
+	}
+	s.out.WriteString(s.ti.TParm(set, int(tcell.FindColor(c, s.palette))))
+}
+
+// cursorRow returns the terminal row of the cursor, counting from 1, by
+// asking the terminal for the cursor position.
+func cursorRow(tty *os.File) (int, error) {
+	saved, err := stty(tty, "-g")
+	if err != nil {
+		return 0, err
+	}
+	// wait at most half a second for the reply
+	if _, err := stty(tty, "raw", "-echo", "min", "0", "time", "5"); err != nil {
+		return 0, err
+	}
+	defer stty(tty, strings.TrimSpace(saved))
+
+	if _, err := tty.WriteString("\x1b[6n"); err != nil {
+		return 0, err
+	}
+	var reply []byte
+	buf := make([]byte, 32)
+	deadline := time.Now().Add(time.Second)
+	for !bytes.HasSuffix(reply, []byte("R")) && time.Now().Before(deadline) {
+		n, err := tty.Read(buf)
+		if err != nil || n == 0 {
+			break
+		}
+		reply = append(reply, buf[:n]...)
+	}
+	// the reply is ESC [ row ; col R
+	start := bytes.LastIndex(reply, []byte("\x1b["))
+	if start == -1 {
+		return 0, fmt.Errorf("no cursor position reported")
+	}
+	var row, col int
+	if _, err := fmt.Sscanf(string(reply[start:]), "\x1b[%d;%dR", &row, &col); err != nil {
+		return 0, err
+	}
+	return row, nil
+}
+
+// stty runs stty with args on tty.
+func stty(tty *os.File, args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = tty
+	out, err := cmd.Output()
+	return string(out), err
+}
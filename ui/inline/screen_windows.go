@@ -0,0 +1,13 @@
+package inline
+
+import (
+	"errors"
+
+	"github.com/gdamore/tcell"
+)
+
+// NewScreen is not supported on windows, whose console cannot be drawn
+// on without taking it over.
+func NewScreen(height Height) (tcell.Screen, error) {
+	return nil, errors.New("inline mode is not supported on windows")
+}
@@ -1,23 +1,53 @@
 package inputfield
 
 import (
+	"regexp"
+
 	"github.com/gdamore/tcell"
 	"github.com/jwdevantier/spellbook/ui"
 	"github.com/jwdevantier/spellbook/utils"
 	"github.com/rivo/tview"
-	"reflect"
 )
 
+// lastWord matches the word before the cursor, as deleted by Ctrl-W
+var lastWord = regexp.MustCompile(`\S+\s*$`)
+
+// CompletionInputField is an input field which, in completion mode, completes
+// a command template: the fixed parts are inserted for the user, who only
+// enters the variables.
+//
+// In completion mode the field keeps its own cursor, and draws the text itself,
+// measuring text in characters as shown on screen rather than in bytes.
+// Positions in the text, like posCompletes, are byte offsets at the start of
+// a character.
 type CompletionInputField struct {
 	*tview.InputField
 
-	toks            []utils.Token
-	posCompletes	[]int
+	toks         []utils.Token
+	posCompletes []int
+
+	previousText string
+
+	// position of the cursor in the text, in completion mode
+	cursor int
+	// first column of the text shown, when it does not fit the field
+	offset int
+	// index of the token of an earlier variable being edited, -1 when
+	// entering the command at its end
+	editing int
 
-	previousText    string
+	// states before each change, and after each undone change
+	undos, redos []state
+	// true if the last change was typing, which is undone as a whole
+	typing bool
 
-	colorVariables	tcell.Color
+	colorVariables      tcell.Color
 	colorNextCompletion tcell.Color
+	// colors of the field, as set on the input field
+	colorFieldText       tcell.Color
+	colorFieldBackground tcell.Color
+	// attributes of variable segments, e.g. to set them apart without colors
+	attrVariables tcell.AttrMask
 }
 
 func NewCompletionInputField() *CompletionInputField {
@@ -25,8 +55,13 @@ func NewCompletionInputField() *CompletionInputField {
 		InputField: tview.NewInputField(),
 
 		// default color for variables
-		colorVariables: tcell.ColorOrange,
+		colorVariables:      tcell.ColorOrange,
 		colorNextCompletion: tview.Styles.ContrastSecondaryTextColor,
+		// defaults of the input field
+		colorFieldText:       tview.Styles.PrimaryTextColor,
+		colorFieldBackground: tview.Styles.ContrastBackgroundColor,
+
+		editing: -1,
 	}
 }
 
@@ -38,6 +73,24 @@ func (ci *CompletionInputField) GetVariableColor() tcell.Color {
 	return ci.colorVariables
 }
 
+func (ci *CompletionInputField) SetVariableAttributes(attr tcell.AttrMask) {
+	ci.attrVariables = attr
+}
+
+func (ci *CompletionInputField) GetVariableAttributes() tcell.AttrMask {
+	return ci.attrVariables
+}
+
+func (ci *CompletionInputField) SetFieldTextColor(color tcell.Color) *tview.InputField {
+	ci.colorFieldText = color
+	return ci.InputField.SetFieldTextColor(color)
+}
+
+func (ci *CompletionInputField) SetFieldBackgroundColor(color tcell.Color) *tview.InputField {
+	ci.colorFieldBackground = color
+	return ci.InputField.SetFieldBackgroundColor(color)
+}
+
 func (ci *CompletionInputField) SetNextCompletionColor(color tcell.Color) {
 	ci.colorNextCompletion = color
 }
@@ -53,17 +106,6 @@ func (ci *CompletionInputField) tokNdx() int {
 	return len(ci.posCompletes) - 1
 }
 
-func (ci *CompletionInputField) cursorPos() int {
-	pos := int(reflect.ValueOf(ci).Elem().FieldByName("cursorPos").Int())
-	// clamping logic fetched from Draw() routine
-	if pos < 0 {
-		return 0
-	} else if pos > len(ci.GetText()) {
-		return len(ci.GetText())
-	}
-	return pos
-}
-
 func (ci *CompletionInputField) posLastCompletion() int {
 	if !ci.CompletionMode() {
 		panic("method called outside completion mode")
@@ -71,17 +113,13 @@ func (ci *CompletionInputField) posLastCompletion() int {
 	return ci.posCompletes[len(ci.posCompletes)-1]
 }
 
-func (ci *CompletionInputField) atCompletionPos() bool {
-	return ci.cursorPos() == ci.posLastCompletion()
-}
-
 func (ci *CompletionInputField) CompletionMode() bool {
 	return ci.toks != nil
 }
 
 func (ci *CompletionInputField) EnterCompletionMode(cmd string) error {
 	if ci.CompletionMode() {
-		ci.exitCompletionMode()
+		ci.ExitCompletionMode()
 	}
 	toks, err := utils.ParseCmd(cmd)
 	if err != nil {
@@ -90,23 +128,34 @@ func (ci *CompletionInputField) EnterCompletionMode(cmd string) error {
 	ci.toks = toks
 	ci.previousText = ci.GetText()
 
-	ci.SetText("")
+	ci.setText("")
 	ci.posCompletes = []int{0}
-	ci.complete()
+	ci.editing = -1
+	ci.offset = 0
+	ci.Complete()
+	ci.undos, ci.redos = nil, nil
 	return nil
 }
 
-func (ci *CompletionInputField) exitCompletionMode() {
-	ci.SetText(ci.previousText)
+// ExitCompletionMode discards the completion, restoring the text entered before it.
+func (ci *CompletionInputField) ExitCompletionMode() {
 	ci.toks = nil
 	ci.posCompletes = nil
+	ci.editing = -1
+	ci.undos, ci.redos = nil, nil
 
 	ci.SetText(ci.previousText)
 	ci.previousText = ""
 }
 
+// setText replaces the text, moving the cursor to its end.
+func (ci *CompletionInputField) setText(text string) {
+	ci.SetText(text)
+	ci.cursor = len(text)
+}
+
 func (ci *CompletionInputField) cursorAtLineEnd() bool {
-	return ci.cursorPos() == len(ci.GetText())
+	return ci.cursor == len(ci.GetText())
 }
 
 func (ci *CompletionInputField) deleteLastCompletion() {
@@ -122,100 +171,142 @@ func (ci *CompletionInputField) deleteLastCompletion() {
 	default:
 		ci.posCompletes = ci.posCompletes[:len(ci.posCompletes)-1]
 		endPos := ci.posCompletes[len(ci.posCompletes)-1]
-		ci.SetText(ci.GetText()[0:endPos])
+		ci.setText(ci.GetText()[0:endPos])
 	}
-	// TODO - if last completion => empty input => exit completion mode
-	//		VERIFY THIS
+	// deleting the first completion leaves nothing to complete
 	if ci.GetText() == "" {
-		ci.exitCompletionMode()
+		ci.ExitCompletionMode()
 	}
 }
 
-func (ci *CompletionInputField) complete() {
+// Complete inserts the fixed parts of the command up to the next variable,
+// closing the variable being entered, if any. While editing an earlier
+// variable, it moves on to the next variable instead.
+func (ci *CompletionInputField) Complete() {
 	if !ci.CompletionMode() {
 		panic("method called outside completion mode")
 	}
-	if !ci.cursorAtLineEnd() {
+	if ci.editing >= 0 {
+		ci.NextVariable()
 		return
 	}
+	ci.change(false, ci.complete)
+}
+
+func (ci *CompletionInputField) complete() {
+	ci.cursor = len(ci.GetText())
 
-	Loop:
+Loop:
 	for i := ci.tokNdx(); i < len(ci.toks); i++ {
 		tok := ci.toks[i]
 		switch tok.Type {
 		case utils.TokVar:
 			// true iff 1+ characters have been written in place of the variable
-			varHasInput := ci.cursorPos() > ci.posLastCompletion()
+			varHasInput := ci.cursor > ci.posLastCompletion()
 			// true iff this variable block is not the last bit of the command
 			// (if it is, do not close/end it - all input from here on out belongs to the var)
 			notLastToken := len(ci.posCompletes) < len(ci.toks)
 			if varHasInput && notLastToken {
-				// TODO: won't I need to treat this also as a completion?
-				ci.posCompletes = append(ci.posCompletes, ci.cursorPos())
+				ci.posCompletes = append(ci.posCompletes, ci.cursor)
 			} else {
 				break Loop
 			}
 		case utils.TokLiteral:
-			ci.SetText(ci.GetText() + tok.Lexeme)
-			ci.posCompletes = append(ci.posCompletes, ci.cursorPos())
+			ci.setText(ci.GetText() + tok.Lexeme)
+			ci.posCompletes = append(ci.posCompletes, ci.cursor)
+		}
+	}
+}
+
+// segment is a part of the text drawn in one style
+type segment struct {
+	text  string
+	style tcell.Style
+}
+
+// segments splits the text into the completed literals and variables, in
+// their styles, followed by the preview of the next literal.
+func (ci *CompletionInputField) segments(textStyle tcell.Style) []segment {
+	varStyle := withAttributes(textStyle.Foreground(ci.colorVariables), ci.attrVariables)
+	text := ci.GetText()
+	var segments []segment
+	for i := 0; i <= ci.tokNdx(); i++ {
+		start, end := ci.slot(i)
+		style := textStyle
+		if i < len(ci.toks) && ci.toks[i].Type == utils.TokVar {
+			style = varStyle
 		}
+		segments = append(segments, segment{text[start:end], style})
 	}
+	if previewTok := ci.nextLiteralTok(); previewTok != nil {
+		segments = append(segments, segment{previewTok.Lexeme, textStyle.Foreground(ci.colorNextCompletion)})
+	}
+	return segments
 }
 
 func (ci *CompletionInputField) Draw(screen tcell.Screen) {
 	ci.InputField.Draw(screen)
 
-	if ! ci.CompletionMode() {
+	if !ci.CompletionMode() {
 		return
 	}
 
-	// Only draw these additional bits if in auto-completion mode
-	x, y, width, _ := ci.InputField.GetInnerRect()
-
+	// Only draw these additional bits if in auto-completion mode: the text is
+	// drawn again, coloring the variables and showing the text inserted next
+	// time TAB (auto-complete) is used after the input.
+	x, y, width, height := ci.InputField.GetInnerRect()
+	labelWidth := tview.TaggedStringWidth(ci.GetLabel())
+	x += labelWidth
 	fieldWidth := ci.InputField.GetFieldWidth()
-	if fieldWidth == 0 { // extend as much as possible
-		fieldWidth = width
+	if fieldWidth == 0 || fieldWidth > width-labelWidth {
+		fieldWidth = width - labelWidth
+	}
+	if height < 1 || fieldWidth < 1 {
+		return
+	}
+	textStyle := tcell.StyleDefault.Background(ci.colorFieldBackground).Foreground(ci.colorFieldText)
+	for i := 0; i < fieldWidth; i++ {
+		screen.SetContent(x+i, y, ' ', nil, textStyle)
 	}
 
-	// Show text inserted next time TAB (auto-complete) is used
-	// start drawing AFTER given input
-	offset := len(ci.GetLabel()) + len(ci.GetText())
-	previewTok := ci.nextLiteralTok()
-	if previewTok != nil {
-		tview.Print(
-			screen, previewTok.Lexeme,
-			offset + x, y, fieldWidth - offset,
-			tview.AlignLeft, ci.colorNextCompletion)
+	// scroll the text to keep the cursor in the field
+	cursorColumn := stringWidth(ci.GetText()[:ci.cursor])
+	if cursorColumn < ci.offset {
+		ci.offset = cursorColumn
+	} else if cursorColumn-ci.offset > fieldWidth-1 {
+		ci.offset = cursorColumn - fieldWidth + 1
+	}
+	if stringWidth(ci.GetText()) < fieldWidth {
+		ci.offset = 0
 	}
 
-	// Draw variable segments of command with a different color
-	fullText := ci.GetText()
-	for i := 0; i <= ci.tokNdx(); i++ {
-		// TODO: kludge. w/o it TAB @ line end crashes program
-		// 		(as we go out of range of ci.toks index)
-		//		In all other scenarios, it is needed
-		if len(ci.toks) == i {
-			break
-		}
-		tok := ci.toks[i]
-		if tok.Type != utils.TokVar {
-			continue
-		}
-		startPos := ci.posCompletes[i]
-		var endPos int
-		if i == len(ci.posCompletes) - 1 {
-			endPos = len(ci.GetText())
-		} else {
-			endPos = ci.posCompletes[i+1]
+	column := 0
+	for _, seg := range ci.segments(textStyle) {
+		for _, g := range graphemes(seg.text) {
+			pos := column - ci.offset
+			column += g.width
+			if pos < 0 || pos+g.width > fieldWidth {
+				continue
+			}
+			screen.SetContent(x+pos, y, g.runes[0], g.runes[1:], seg.style)
 		}
-		tview.Print(
-			screen, fullText[startPos:endPos],
-			len(ci.GetLabel()) + x + startPos, y,
-			fieldWidth - len(ci.GetLabel()) - startPos,
-			tview.AlignLeft, ci.colorVariables)
+	}
+
+	if ci.HasFocus() {
+		screen.ShowCursor(x+cursorColumn-ci.offset, y)
 	}
 }
 
+// withAttributes returns style with the attributes in attr set.
+func withAttributes(style tcell.Style, attr tcell.AttrMask) tcell.Style {
+	return style.
+		Bold(attr&tcell.AttrBold != 0).
+		Blink(attr&tcell.AttrBlink != 0).
+		Reverse(attr&tcell.AttrReverse != 0).
+		Underline(attr&tcell.AttrUnderline != 0).
+		Dim(attr&tcell.AttrDim != 0)
+}
+
 func (ci *CompletionInputField) nextLiteralTok() *utils.Token {
 	for i := ci.tokNdx(); i < len(ci.toks); i++ {
 		tok := ci.toks[i]
@@ -227,7 +318,9 @@ func (ci *CompletionInputField) nextLiteralTok() *utils.Token {
 }
 
 func (ci *CompletionInputField) CompletionDone() bool {
-	if !(len(ci.posCompletes) >= len(ci.toks)) {
+	if len(ci.toks) == 0 {
+		return true
+	} else if !(len(ci.posCompletes) >= len(ci.toks)) {
 		// not at last completion
 		return false
 	} else if ci.toks[len(ci.toks)-1].Type == utils.TokVar && len(ci.GetText()) == ci.posLastCompletion() {
@@ -237,68 +330,286 @@ func (ci *CompletionInputField) CompletionDone() bool {
 	return true
 }
 
+// CurrentVar returns the name of the variable being completed, or edited, if any.
+func (ci *CompletionInputField) CurrentVar() (string, bool) {
+	if !ci.CompletionMode() {
+		return "", false
+	}
+	if ci.editing >= 0 {
+		return ci.toks[ci.editing].Lexeme, true
+	}
+	if ci.tokNdx() >= len(ci.toks) {
+		return "", false
+	}
+	tok := ci.toks[ci.tokNdx()]
+	if tok.Type != utils.TokVar {
+		return "", false
+	}
+	return tok.Lexeme, true
+}
+
+// Values returns the value entered for each variable completed so far.
+// If a variable occurs more than once, the last value is returned.
+func (ci *CompletionInputField) Values() map[string]string {
+	values := make(map[string]string)
+	text := ci.GetText()
+	for i, tok := range ci.toks {
+		if i >= len(ci.posCompletes) {
+			break
+		}
+		if tok.Type != utils.TokVar {
+			continue
+		}
+		start, end := ci.slot(i)
+		values[tok.Lexeme] = text[start:end]
+	}
+	return values
+}
+
+// slot returns the start and end of the text entered in place of token i.
+func (ci *CompletionInputField) slot(i int) (int, int) {
+	if i+1 < len(ci.posCompletes) {
+		return ci.posCompletes[i], ci.posCompletes[i+1]
+	}
+	return ci.posCompletes[i], len(ci.GetText())
+}
+
+// Editing reports whether an earlier variable is being edited.
+func (ci *CompletionInputField) Editing() bool {
+	return ci.editing >= 0
+}
+
+// edit starts editing the variable of token i, with the cursor at its end.
+func (ci *CompletionInputField) edit(i int) {
+	ci.typing = false
+	ci.editing = i
+	_, ci.cursor = ci.slot(i)
+}
+
+// PrevVariable moves back to the variable before the one being entered, or
+// edited, to edit it in place. It returns false if there is none.
+func (ci *CompletionInputField) PrevVariable() bool {
+	if !ci.CompletionMode() {
+		return false
+	}
+	current := ci.tokNdx()
+	if ci.editing >= 0 {
+		current = ci.editing
+	}
+	for i := current - 1; i >= 0; i-- {
+		if ci.toks[i].Type == utils.TokVar {
+			ci.edit(i)
+			return true
+		}
+	}
+	return false
+}
+
+// NextVariable moves on from the variable being edited to the next one,
+// returning to the end of the command after the last completed variable.
+// It returns false if no earlier variable is being edited.
+func (ci *CompletionInputField) NextVariable() bool {
+	if !ci.CompletionMode() || ci.editing < 0 {
+		return false
+	}
+	for i := ci.editing + 1; i < ci.tokNdx(); i++ {
+		if ci.toks[i].Type == utils.TokVar {
+			ci.edit(i)
+			return true
+		}
+	}
+	ci.editing = -1
+	ci.typing = false
+	ci.cursor = len(ci.GetText())
+	return true
+}
+
+// activeSlot returns the index of the token being entered or edited, and the
+// bounds within which its text is changed.
+func (ci *CompletionInputField) activeSlot() (int, int, int) {
+	i := ci.tokNdx()
+	if ci.editing >= 0 {
+		i = ci.editing
+	}
+	start, end := ci.slot(i)
+	return i, start, end
+}
+
+// replace replaces text[from:to], inside the token being entered or edited,
+// with insert, moving the completions following it along.
+func (ci *CompletionInputField) replace(from, to int, insert string) {
+	i, _, _ := ci.activeSlot()
+	text := ci.GetText()
+	delta := len(insert) - (to - from)
+	for j := i + 1; j < len(ci.posCompletes); j++ {
+		ci.posCompletes[j] += delta
+	}
+	ci.SetText(text[:from] + insert + text[to:])
+	ci.cursor = from + len(insert)
+}
+
+// state is the completion as saved by each change, to be restored by Undo and Redo
+type state struct {
+	text         string
+	posCompletes []int
+	cursor       int
+	editing      int
+}
+
+func (ci *CompletionInputField) state() state {
+	return state{
+		text:         ci.GetText(),
+		posCompletes: append([]int(nil), ci.posCompletes...),
+		cursor:       ci.cursor,
+		editing:      ci.editing,
+	}
+}
+
+func (ci *CompletionInputField) restore(s state) {
+	ci.SetText(s.text)
+	ci.posCompletes = s.posCompletes
+	ci.cursor = s.cursor
+	ci.editing = s.editing
+	ci.typing = false
+}
+
+// changed reports whether the text, or its completions, differ from s.
+func (ci *CompletionInputField) changed(s state) bool {
+	if s.text != ci.GetText() || len(s.posCompletes) != len(ci.posCompletes) {
+		return true
+	}
+	for i, pos := range s.posCompletes {
+		if ci.posCompletes[i] != pos {
+			return true
+		}
+	}
+	return false
+}
+
+// change makes a change by calling fn, saving the state before it to be
+// undone. Consecutive typing is undone at once.
+func (ci *CompletionInputField) change(typing bool, fn func()) {
+	before := ci.state()
+	fn()
+	// deleting the last completion can end completion mode
+	if !ci.CompletionMode() || !ci.changed(before) {
+		return
+	}
+	if !typing || !ci.typing {
+		ci.undos = append(ci.undos, before)
+	}
+	ci.redos = nil
+	ci.typing = typing
+}
+
+// Undo undoes the last change to the completion, returning false if there
+// is none.
+func (ci *CompletionInputField) Undo() bool {
+	if !ci.CompletionMode() || len(ci.undos) == 0 {
+		return false
+	}
+	ci.redos = append(ci.redos, ci.state())
+	ci.restore(ci.undos[len(ci.undos)-1])
+	ci.undos = ci.undos[:len(ci.undos)-1]
+	return true
+}
+
+// Redo redoes the last change undone, returning false if there is none.
+func (ci *CompletionInputField) Redo() bool {
+	if !ci.CompletionMode() || len(ci.redos) == 0 {
+		return false
+	}
+	ci.undos = append(ci.undos, ci.state())
+	ci.restore(ci.redos[len(ci.redos)-1])
+	ci.redos = ci.redos[:len(ci.redos)-1]
+	return true
+}
+
+// SetInputCapture sets the handler of key events. Events not handled by it
+// are passed on to the input field, which keeps the user from editing the
+// completed parts of the command.
 func (ci *CompletionInputField) SetInputCapture(handler func(event *tcell.EventKey) *tcell.EventKey) {
-	ci.InputField.SetInputCapture(func (event *tcell.EventKey) *tcell.EventKey {
-		out := ci.defaultInputCapture(event)
+	ci.InputField.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		out := handler(event)
 		if out == nil {
 			return nil
 		}
-		return handler(out)
+		return ci.defaultInputCapture(out)
 	})
 }
 
-func (ci *CompletionInputField) onBackspace(event *tcell.EventKey) *tcell.EventKey {
-	cursorPos := ci.cursorPos()
-	if cursorPos == 0 {
-		// nothing to delete
-	} else if cursorPos != ci.posLastCompletion() {
-		// deleting some regular character, allow this by bubbling up the event
-		return event
-	} else if ci.cursorAtLineEnd() {
+// onBackspace deletes the character before the cursor, and at the start of the
+// variable being entered, the block before it: a literal as a whole, a
+// variable a character at a time.
+func (ci *CompletionInputField) onBackspace() {
+	_, start, _ := ci.activeSlot()
+	if ci.cursor > start {
+		ci.replace(prevGrapheme(ci.GetText(), start, ci.cursor), ci.cursor, "")
+	} else if ci.editing < 0 && ci.cursor > 0 && ci.cursorAtLineEnd() {
 		// we are at the point of deleting part of the prior block
 		// and there is no input following the cursor.
-
 		lastTok := ci.toks[ci.tokNdx()-1]
-		// the token we are deleting into is a user-provided variable value
-		// => delete char-by-char
 		if lastTok.Type == utils.TokVar {
-			// pop the completion value (TODO: I think this is ALWAYS going to be necessary)
-			if cursorPos == ci.posCompletes[ci.tokNdx()] {
-				ci.posCompletes = ci.posCompletes[:len(ci.posCompletes)-1]
-			}
-			return event
+			// continue entering the variable, which a variable emptied by
+			// editing it, has nothing to delete of
+			ci.posCompletes = ci.posCompletes[:len(ci.posCompletes)-1]
+			ci.onBackspace()
+			return
 		}
-
 		ci.deleteLastCompletion()
 	}
-	return nil
 }
 
-func (ci *CompletionInputField) defaultInputCapture(event *tcell.EventKey) *tcell.EventKey {
+// onKey handles the keys editing the text in completion mode, which keep the
+// cursor, and any change, within the variable being entered or edited.
+func (ci *CompletionInputField) onKey(event *tcell.EventKey) *tcell.EventKey {
+	text := ci.GetText()
+	_, start, end := ci.activeSlot()
+	typing := false
 	switch event.Key() {
-	case tcell.KeyTab:
-		if ci.CompletionMode() {
-			ci.complete()
-			return nil
-		}
-	case tcell.KeyEscape:
-		if ci.CompletionMode() {
-			ci.exitCompletionMode()
-			return nil
-		}
-	case tcell.KeyLeft:
-		if ci.CompletionMode() && ci.cursorPos() == ci.posLastCompletion() {
-			return nil
+	case tcell.KeyRune:
+		if event.Modifiers()&tcell.ModAlt == 0 {
+			typing = true
+			ci.change(true, func() {
+				ci.replace(ci.cursor, ci.cursor, string(event.Rune()))
+			})
 		}
 	case tcell.KeyBackspace, tcell.KeyBackspace2:
-		if ci.CompletionMode() {
-			return ci.onBackspace(event)
-		}
-	case tcell.KeyHome, tcell.KeyCtrlA, tcell.KeyCtrlW, tcell.KeyCtrlU:
-		// Disallow any action which moves behind into line
-		if ci.CompletionMode() {
-			return nil
+		ci.change(false, ci.onBackspace)
+	case tcell.KeyDelete:
+		if ci.cursor < end {
+			ci.change(false, func() {
+				ci.replace(ci.cursor, nextGrapheme(text, ci.cursor, end), "")
+			})
 		}
+	case tcell.KeyLeft:
+		ci.cursor = prevGrapheme(text, start, ci.cursor)
+	case tcell.KeyRight:
+		ci.cursor = nextGrapheme(text, ci.cursor, end)
+	case tcell.KeyHome, tcell.KeyCtrlA:
+		ci.cursor = start
+	case tcell.KeyEnd, tcell.KeyCtrlE:
+		ci.cursor = end
+	case tcell.KeyCtrlU:
+		ci.change(false, func() { ci.replace(start, ci.cursor, "") })
+	case tcell.KeyCtrlK:
+		ci.change(false, func() { ci.replace(ci.cursor, end, "") })
+	case tcell.KeyCtrlW:
+		word := lastWord.FindString(text[start:ci.cursor])
+		ci.change(false, func() { ci.replace(ci.cursor-len(word), ci.cursor, "") })
+	case tcell.KeyEnter, tcell.KeyEscape, tcell.KeyTab, tcell.KeyBacktab:
+		return event
+	}
+	// typing after moving the cursor is undone separately
+	if !typing {
+		ci.typing = false
+	}
+	return nil
+}
+
+func (ci *CompletionInputField) defaultInputCapture(event *tcell.EventKey) *tcell.EventKey {
+	if ci.CompletionMode() {
+		return ci.onKey(event)
 	}
 	return event
 }
@@ -313,8 +624,13 @@ func (ci *CompletionInputField) Style(theme *ui.Base16Theme) {
 	ci.SetNextCompletionColor(theme.BrightCyan)
 
 	ci.SetVariableColor(theme.BrightGreen)
+	if theme.Monochrome {
+		ci.SetVariableAttributes(tcell.AttrUnderline)
+	} else {
+		ci.SetVariableAttributes(0)
+	}
 	ci.SetFieldBackgroundColor(theme.BrightBlack)
 	ci.SetFieldTextColor(theme.White)
 
 	ci.SetBorderColor(theme.Cyan)
-}
\ No newline at end of file
+}
@@ -0,0 +1,205 @@
+package inputfield
+
+import (
+	"testing"
+
+	"github.com/gdamore/tcell"
+)
+
+func TestEditEarlierVariable(t *testing.T) {
+	ci := NewCompletionInputField()
+	ci.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey { return event })
+	handle := ci.InputHandler()
+	press := func(key tcell.Key, r rune) {
+		handle(tcell.NewEventKey(key, r, tcell.ModNone), nil)
+	}
+	typeText := func(text string) {
+		for _, r := range text {
+			press(tcell.KeyRune, r)
+		}
+	}
+	expect := func(step, text string, values map[string]string) {
+		t.Helper()
+		if ci.GetText() != text {
+			t.Errorf("%s: expected text '%s', got '%s'", step, text, ci.GetText())
+		}
+		got := ci.Values()
+		for name, value := range values {
+			if got[name] != value {
+				t.Errorf("%s: expected %s='%s', got '%s'", step, name, value, got[name])
+			}
+		}
+	}
+
+	if err := ci.EnterCompletionMode("cp %(src) %(dst) -v"); err != nil {
+		t.Fatal(err)
+	}
+	typeText("a.txt")
+	ci.Complete()
+	typeText("b")
+	expect("completed", "cp a.txt b", map[string]string{"src": "a.txt", "dst": "b"})
+
+	if !ci.PrevVariable() || !ci.Editing() {
+		t.Fatal("expected to edit src")
+	}
+	if name, _ := ci.CurrentVar(); name != "src" {
+		t.Errorf("expected the current variable to be src, got '%s'", name)
+	}
+	press(tcell.KeyBackspace2, 0)
+	press(tcell.KeyBackspace2, 0)
+	press(tcell.KeyBackspace2, 0)
+	typeText("md")
+	expect("value changed", "cp a.md b", map[string]string{"src": "a.md", "dst": "b"})
+
+	// the cursor stays within the variable
+	press(tcell.KeyHome, 0)
+	press(tcell.KeyLeft, 0)
+	press(tcell.KeyBackspace2, 0)
+	typeText("/")
+	press(tcell.KeyEnd, 0)
+	press(tcell.KeyDelete, 0)
+	expect("fixed parts kept", "cp /a.md b", map[string]string{"src": "/a.md", "dst": "b"})
+
+	// emptied, backspace at the end deletes through the variable into the literal
+	press(tcell.KeyCtrlU, 0)
+	expect("emptied", "cp  b", map[string]string{"src": "", "dst": "b"})
+	ci.NextVariable()
+	if ci.Editing() {
+		t.Fatal("expected to be back at the end of the command")
+	}
+	ci.Complete()
+	expect("completed after editing", "cp  b -v", map[string]string{"src": "", "dst": "b"})
+	if !ci.CompletionDone() {
+		t.Error("expected completion to be done")
+	}
+	press(tcell.KeyBackspace2, 0)
+	press(tcell.KeyBackspace2, 0)
+	press(tcell.KeyBackspace2, 0)
+	expect("deleted dst", "cp ", nil)
+	press(tcell.KeyBackspace2, 0)
+	if ci.CompletionMode() {
+		t.Errorf("expected the emptied src to be deleted with 'cp ', got '%s'", ci.GetText())
+	}
+}
+
+func TestWideCharacters(t *testing.T) {
+	screen := tcell.NewSimulationScreen("UTF-8")
+	if err := screen.Init(); err != nil {
+		t.Fatal(err)
+	}
+	screen.SetSize(30, 1)
+
+	ci := NewCompletionInputField()
+	ci.SetLabel("> ")
+	ci.SetRect(0, 0, 30, 1)
+	ci.Focus(nil)
+	ci.SetVariableColor(tcell.ColorGreen)
+	ci.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey { return event })
+	handle := ci.InputHandler()
+
+	if err := ci.EnterCompletionMode("ls %(dir)/ü/%(file)"); err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range "日本" {
+		handle(tcell.NewEventKey(tcell.KeyRune, r, tcell.ModNone), nil)
+	}
+	ci.Complete()
+	// e and a combining acute accent, deleted as one character
+	for _, r := range "xe\u0301" {
+		handle(tcell.NewEventKey(tcell.KeyRune, r, tcell.ModNone), nil)
+	}
+	handle(tcell.NewEventKey(tcell.KeyBackspace2, 0, tcell.ModNone), nil)
+	if values := ci.Values(); values["dir"] != "日本" || values["file"] != "x" {
+		t.Errorf("unexpected values %v", values)
+	}
+
+	ci.Draw(screen)
+	// "> ls " takes 5 columns, each of 日本 two
+	cells := []struct {
+		x     int
+		r     rune
+		color tcell.Color
+	}{
+		{5, '日', tcell.ColorGreen},
+		{7, '本', tcell.ColorGreen},
+		{9, '/', ci.colorFieldText},
+		{10, 'ü', ci.colorFieldText},
+		{12, 'x', tcell.ColorGreen},
+	}
+	for _, c := range cells {
+		r, _, style, _ := screen.GetContent(c.x, 0)
+		if fg, _, _ := style.Decompose(); r != c.r || fg != c.color {
+			t.Errorf("column %d: expected '%c' in %v, got '%c' in %v", c.x, c.r, c.color, r, fg)
+		}
+	}
+
+	// the cursor is placed by columns, not bytes
+	ci.PrevVariable()
+	handle(tcell.NewEventKey(tcell.KeyLeft, 0, tcell.ModNone), nil)
+	ci.Draw(screen)
+	screen.Show()
+	if x, _, _ := screen.(tcell.SimulationScreen).GetCursor(); x != 7 {
+		t.Errorf("expected the cursor in column 7, got %d", x)
+	}
+}
+
+func TestUndo(t *testing.T) {
+	ci := NewCompletionInputField()
+	ci.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey { return event })
+	handle := ci.InputHandler()
+	typeText := func(text string) {
+		for _, r := range text {
+			handle(tcell.NewEventKey(tcell.KeyRune, r, tcell.ModNone), nil)
+		}
+	}
+	expect := func(step, text string) {
+		t.Helper()
+		if ci.GetText() != text {
+			t.Errorf("%s: expected text '%s', got '%s'", step, text, ci.GetText())
+		}
+	}
+
+	if err := ci.EnterCompletionMode("git push %(remote) %(branch)"); err != nil {
+		t.Fatal(err)
+	}
+	if ci.Undo() {
+		t.Error("expected nothing to undo after entering completion mode")
+	}
+	typeText("origin")
+	ci.Complete()
+	typeText("main")
+	// deletes main, then the space completed before it
+	for i := 0; i < 5; i++ {
+		handle(tcell.NewEventKey(tcell.KeyBackspace2, 0, tcell.ModNone), nil)
+	}
+	expect("deleted", "git push origin")
+
+	ci.Undo()
+	expect("undo deleting the space", "git push origin ")
+	ci.Undo()
+	ci.Undo()
+	ci.Undo()
+	ci.Undo()
+	expect("undo deleting main", "git push origin main")
+	ci.Undo()
+	expect("undo typing main", "git push origin ")
+	ci.Undo()
+	expect("undo completing", "git push origin")
+	ci.Undo()
+	expect("undo typing origin", "git push ")
+	if ci.Undo() {
+		t.Error("expected nothing left to undo")
+	}
+
+	ci.Redo()
+	ci.Redo()
+	expect("redo", "git push origin ")
+	if values := ci.Values(); values["remote"] != "origin" {
+		t.Errorf("expected remote to be completed again, got %v", values)
+	}
+	typeText("dev")
+	if ci.Redo() {
+		t.Error("expected a change to clear the changes to redo")
+	}
+	expect("changed", "git push origin dev")
+}
@@ -0,0 +1,63 @@
+package inputfield
+
+import (
+	"github.com/mattn/go-runewidth"
+	"github.com/rivo/uniseg"
+)
+
+// grapheme is a character as shown on screen, made up of one or more runes
+type grapheme struct {
+	runes []rune
+	// start and end of the runes in the text, in bytes
+	start, end int
+	// number of cells taken up on screen
+	width int
+}
+
+// graphemes splits text into the characters shown on screen, measured as
+// tview does when drawing text.
+func graphemes(text string) []grapheme {
+	var gs []grapheme
+	g := uniseg.NewGraphemes(text)
+	for g.Next() {
+		start, end := g.Positions()
+		width := 0
+		for _, r := range g.Runes() {
+			// the first rune taking up any space decides the width
+			if width = runewidth.RuneWidth(r); width > 0 {
+				break
+			}
+		}
+		gs = append(gs, grapheme{runes: g.Runes(), start: start, end: end, width: width})
+	}
+	return gs
+}
+
+// stringWidth returns the number of cells needed to show text.
+func stringWidth(text string) int {
+	width := 0
+	for _, g := range graphemes(text) {
+		width += g.width
+	}
+	return width
+}
+
+// prevGrapheme returns the start of the character before pos in text[from:],
+// or from if there is none.
+func prevGrapheme(text string, from, pos int) int {
+	gs := graphemes(text[from:pos])
+	if len(gs) == 0 {
+		return from
+	}
+	return from + gs[len(gs)-1].start
+}
+
+// nextGrapheme returns the end of the character after pos in text[:to], or to
+// if there is none.
+func nextGrapheme(text string, pos, to int) int {
+	gs := graphemes(text[pos:to])
+	if len(gs) == 0 {
+		return to
+	}
+	return pos + gs[0].end
+}
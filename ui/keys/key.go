@@ -0,0 +1,179 @@
+package keys
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gdamore/tcell"
+)
+
+// Key is a key press, a key or a rune with modifiers.
+type Key struct {
+	Key  tcell.Key
+	Rune rune
+	Mod  tcell.ModMask
+}
+
+// names of the special keys, e.g. "pgup", and some aliases
+var keyNames = map[string]tcell.Key{
+	"escape":    tcell.KeyEsc,
+	"return":    tcell.KeyEnter,
+	"backspace": tcell.KeyBackspace2,
+	"del":       tcell.KeyDelete,
+	"ins":       tcell.KeyInsert,
+	"pageup":    tcell.KeyPgUp,
+	"pagedown":  tcell.KeyPgDn,
+}
+
+// ctrlAliases are Ctrl keys which terminals send as the same key code as
+// another key, e.g. Ctrl-H as Backspace, so they cannot be bound on their own
+var ctrlAliases = map[tcell.Key]string{
+	tcell.KeyCtrlH: "backspace",
+	tcell.KeyCtrlI: "tab",
+	tcell.KeyCtrlM: "enter",
+}
+
+func init() {
+	for key, name := range tcell.KeyNames {
+		name = strings.ToLower(name)
+		// control keys are spelled with the ctrl- modifier, e.g. ctrl-n
+		if strings.HasPrefix(name, "ctrl-") || name == "backspace" || name == "backspace2" {
+			continue
+		}
+		keyNames[name] = key
+	}
+}
+
+// normalize makes keys read from the config and key events equal: control
+// characters, backspace and Shift-Tab have their own key codes, whatever
+// modifiers the terminal reports, and runes only take the Alt modifier.
+func (k Key) normalize() Key {
+	switch {
+	case k.Key == tcell.KeyBackspace:
+		k.Key = tcell.KeyBackspace2
+	case k.Key == tcell.KeyBacktab:
+		k.Mod &^= tcell.ModShift
+	case k.Key == tcell.KeyRune:
+		k.Mod &= tcell.ModAlt
+		return k
+	}
+	k.Rune = 0
+	switch k.Key {
+	case tcell.KeyTab, tcell.KeyEnter, tcell.KeyEsc:
+		// typed without Ctrl, Ctrl-Enter is another key
+	default:
+		if k.Key < ' ' {
+			k.Mod &^= tcell.ModCtrl
+		}
+	}
+	k.Mod &^= tcell.ModMeta
+	return k
+}
+
+// Printable reports whether the key types a character, rather than being a
+// special key or pressed with Alt.
+func (k Key) Printable() bool {
+	return k.Key == tcell.KeyRune && k.Mod == tcell.ModNone
+}
+
+// KeyOf returns the key pressed in event.
+func KeyOf(event *tcell.EventKey) Key {
+	return Key{Key: event.Key(), Rune: event.Rune(), Mod: event.Modifiers()}.normalize()
+}
+
+// ParseKey parses keys like "enter", "ctrl-n", "alt-p", "shift-tab" or "?".
+func ParseKey(s string) (Key, error) {
+	k := Key{}
+	name := s
+	for {
+		lower := strings.ToLower(name)
+		if strings.HasPrefix(lower, "ctrl-") && len(name) > len("ctrl-") {
+			k.Mod |= tcell.ModCtrl
+			name = name[len("ctrl-"):]
+		} else if strings.HasPrefix(lower, "alt-") && len(name) > len("alt-") {
+			k.Mod |= tcell.ModAlt
+			name = name[len("alt-"):]
+		} else if strings.HasPrefix(lower, "shift-") && len(name) > len("shift-") {
+			k.Mod |= tcell.ModShift
+			name = name[len("shift-"):]
+		} else {
+			break
+		}
+	}
+
+	if r, size := utf8.DecodeRuneInString(name); size == len(name) && r != utf8.RuneError {
+		switch {
+		case k.Mod&tcell.ModCtrl != 0 && unicode.ToLower(r) >= 'a' && unicode.ToLower(r) <= 'z':
+			k.Key = tcell.KeyCtrlA + tcell.Key(unicode.ToLower(r)-'a')
+			if alias, found := ctrlAliases[k.Key]; found {
+				return k, fmt.Errorf("invalid key '%s', terminals send it as %s, bind %s instead", s, alias, alias)
+			}
+		case k.Mod&tcell.ModCtrl != 0:
+			return k, fmt.Errorf("invalid key '%s', only letters can be pressed with ctrl", s)
+		default:
+			k.Key = tcell.KeyRune
+			k.Rune = r
+			if k.Mod&tcell.ModShift != 0 {
+				k.Rune = unicode.ToUpper(r)
+			}
+		}
+		return k.normalize(), nil
+	}
+
+	lower := strings.ToLower(name)
+	if lower == "space" && k.Mod&tcell.ModCtrl != 0 {
+		k.Key = tcell.KeyCtrlSpace
+		return k.normalize(), nil
+	}
+	if lower == "space" {
+		k.Key, k.Rune = tcell.KeyRune, ' '
+		return k.normalize(), nil
+	}
+	if lower == "tab" && k.Mod&tcell.ModShift != 0 {
+		k.Key = tcell.KeyBacktab
+		return k.normalize(), nil
+	}
+	key, found := keyNames[lower]
+	if !found {
+		return k, fmt.Errorf("invalid key '%s'", s)
+	}
+	k.Key = key
+	return k.normalize(), nil
+}
+
+// String describes the key as shown to the user, e.g. "Alt-P" or "Ctrl-N".
+func (k Key) String() string {
+	mods := ""
+	if k.Mod&tcell.ModCtrl != 0 {
+		mods += "Ctrl-"
+	}
+	if k.Mod&tcell.ModAlt != 0 {
+		mods += "Alt-"
+	}
+	if k.Mod&tcell.ModShift != 0 {
+		mods += "Shift-"
+	}
+	switch k.Key {
+	case tcell.KeyRune:
+		switch {
+		case k.Rune == ' ':
+			return mods + "Space"
+		case unicode.IsUpper(k.Rune):
+			return mods + "Shift-" + string(k.Rune)
+		case mods != "":
+			// Alt-P reads better than Alt-p
+			return mods + string(unicode.ToUpper(k.Rune))
+		}
+		return string(k.Rune)
+	case tcell.KeyBackspace2:
+		return mods + "Backspace"
+	case tcell.KeyBacktab:
+		return mods + "Shift-Tab"
+	}
+	if name, found := tcell.KeyNames[k.Key]; found {
+		return mods + name
+	}
+	return fmt.Sprintf("%sKey[%d]", mods, k.Key)
+}
@@ -0,0 +1,235 @@
+package keys
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gdamore/tcell"
+)
+
+// Action is what the user asks for by pressing a key.
+type Action string
+
+// Actions that can be bound to keys
+const (
+	ActionNone              Action = ""
+	ActionSelectUp          Action = "select-up"
+	ActionSelectDown        Action = "select-down"
+	ActionPageUp            Action = "page-up"
+	ActionPageDown          Action = "page-down"
+	ActionSelectFirst       Action = "select-first"
+	ActionSelectLast        Action = "select-last"
+	ActionToggleMark        Action = "toggle-mark"
+	ActionComplete          Action = "complete"
+	ActionPrevVariable      Action = "prev-variable"
+	ActionNextVariable      Action = "next-variable"
+	ActionUndo              Action = "undo"
+	ActionRedo              Action = "redo"
+	ActionAccept            Action = "accept"
+	ActionRunInline         Action = "run-inline"
+	ActionCopy              Action = "copy"
+	ActionCancel            Action = "cancel"
+	ActionTogglePreview     Action = "toggle-preview"
+	ActionToggleHelp        Action = "toggle-help"
+	ActionScrollOutputUp    Action = "scroll-output-up"
+	ActionScrollOutputDown  Action = "scroll-output-down"
+	ActionToggleHelpOverlay Action = "toggle-help-overlay"
+)
+
+// Actions are all actions, in the order they are documented.
+var Actions = []Action{
+	ActionSelectUp,
+	ActionSelectDown,
+	ActionPageUp,
+	ActionPageDown,
+	ActionSelectFirst,
+	ActionSelectLast,
+	ActionToggleMark,
+	ActionComplete,
+	ActionPrevVariable,
+	ActionNextVariable,
+	ActionUndo,
+	ActionRedo,
+	ActionAccept,
+	ActionRunInline,
+	ActionCopy,
+	ActionCancel,
+	ActionTogglePreview,
+	ActionToggleHelp,
+	ActionScrollOutputUp,
+	ActionScrollOutputDown,
+	ActionToggleHelpOverlay,
+}
+
+// descriptions of the actions, as shown in the help
+var descriptions = map[Action]string{
+	ActionSelectUp:          "select the command above",
+	ActionSelectDown:        "select the command below",
+	ActionPageUp:            "move up a page of commands",
+	ActionPageDown:          "move down a page of commands",
+	ActionSelectFirst:       "select the first command",
+	ActionSelectLast:        "select the last command",
+	ActionToggleMark:        "mark the command, or unmark it, to run several at once",
+	ActionComplete:          "pick the command, then complete up to the next variable",
+	ActionPrevVariable:      "go back to the previous variable to edit it",
+	ActionNextVariable:      "move on to the next variable",
+	ActionUndo:              "undo the last change to the command being completed",
+	ActionRedo:              "redo the last change undone",
+	ActionAccept:            "pick the command, then run it",
+	ActionRunInline:         "pick the command, then run it without leaving spellbook",
+	ActionCopy:              "copy the completed command to the clipboard",
+	ActionCancel:            "stop completing the command, or quit",
+	ActionTogglePreview:     "show or hide the preview of the command",
+	ActionToggleHelp:        "show or hide the program's man page in the preview",
+	ActionScrollOutputUp:    "scroll up the output of commands run inline",
+	ActionScrollOutputDown:  "scroll down the output of commands run inline",
+	ActionToggleHelpOverlay: "show or hide this help",
+}
+
+// Description describes what the action does.
+func (a Action) Description() string {
+	return descriptions[a]
+}
+
+// Bindings are the keys, as read by ParseKey, bound to each action.
+type Bindings map[Action][]string
+
+// DefaultPreset is the name of the preset used if none is configured.
+const DefaultPreset = "default"
+
+var defaultBindings = Bindings{
+	ActionSelectUp:          {"up"},
+	ActionSelectDown:        {"down"},
+	ActionPageUp:            {"pgup"},
+	ActionPageDown:          {"pgdn"},
+	ActionSelectFirst:       {"home"},
+	ActionSelectLast:        {"end"},
+	ActionToggleMark:        {"ctrl-space", "space"},
+	ActionComplete:          {"tab"},
+	ActionPrevVariable:      {"shift-tab", "alt-left"},
+	ActionNextVariable:      {"alt-right"},
+	ActionUndo:              {"ctrl-z"},
+	ActionRedo:              {"ctrl-y"},
+	ActionAccept:            {"enter"},
+	ActionRunInline:         {"alt-enter", "ctrl-enter"},
+	ActionCopy:              {"alt-c"},
+	ActionCancel:            {"esc"},
+	ActionTogglePreview:     {"alt-p"},
+	ActionToggleHelp:        {"alt-h"},
+	ActionScrollOutputUp:    {"alt-up"},
+	ActionScrollOutputDown:  {"alt-down"},
+	ActionToggleHelpOverlay: {"f1", "?"},
+}
+
+// presets change the keys of some actions, the others keep their default keys
+var presets = map[string]Bindings{
+	DefaultPreset: {},
+	"emacs": {
+		ActionSelectUp:    {"up", "ctrl-p"},
+		ActionSelectDown:  {"down", "ctrl-n"},
+		ActionPageUp:      {"pgup", "alt-v"},
+		ActionPageDown:    {"pgdn", "ctrl-v"},
+		ActionSelectFirst: {"home", "alt-<"},
+		ActionSelectLast:  {"end", "alt->"},
+		ActionCancel:      {"esc", "ctrl-g"},
+	},
+	"vi": {
+		ActionSelectUp:   {"up", "ctrl-k", "ctrl-p"},
+		ActionSelectDown: {"down", "ctrl-j", "ctrl-n"},
+		ActionPageUp:     {"pgup", "ctrl-b"},
+		ActionPageDown:   {"pgdn", "ctrl-f"},
+	},
+}
+
+// Presets returns the names of the presets.
+func Presets() []string {
+	names := make([]string, 0, len(presets))
+	for name := range presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Keymap maps keys to the actions they are bound to.
+type Keymap struct {
+	actions map[Key]Action
+	keys    map[Action][]Key
+}
+
+func (km *Keymap) bind(action Action, names []string) error {
+	for _, key := range km.keys[action] {
+		if km.actions[key] == action {
+			delete(km.actions, key)
+		}
+	}
+	km.keys[action] = nil
+	for _, name := range names {
+		key, err := ParseKey(name)
+		if err != nil {
+			return fmt.Errorf("%s: %v", action, err)
+		}
+		km.actions[key] = action
+		km.keys[action] = append(km.keys[action], key)
+	}
+	return nil
+}
+
+// NewKeymap returns the default keymap changed by the preset, if not "", and
+// then by bindings. Binding an action replaces its keys, an action bound to
+// no keys cannot be used.
+func NewKeymap(preset string, bindings Bindings) (*Keymap, error) {
+	if preset == "" {
+		preset = DefaultPreset
+	}
+	presetBindings, found := presets[preset]
+	if !found {
+		return nil, fmt.Errorf("unknown keymap preset '%s', expected one of %s",
+			preset, strings.Join(Presets(), ", "))
+	}
+	for action := range bindings {
+		if _, found := defaultBindings[action]; !found {
+			return nil, fmt.Errorf("unknown action '%s'", action)
+		}
+	}
+	km := &Keymap{
+		actions: make(map[Key]Action),
+		keys:    make(map[Action][]Key),
+	}
+	for _, action := range Actions {
+		if err := km.bind(action, defaultBindings[action]); err != nil {
+			return nil, err
+		}
+	}
+	// a key rebound by the preset or the user is taken from the action it was bound to
+	for _, layer := range []Bindings{presetBindings, bindings} {
+		for _, action := range Actions {
+			if names, found := layer[action]; found {
+				if err := km.bind(action, names); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	for action, keys := range km.keys {
+		kept := keys[:0]
+		for _, key := range keys {
+			if km.actions[key] == action {
+				kept = append(kept, key)
+			}
+		}
+		km.keys[action] = kept
+	}
+	return km, nil
+}
+
+// Action returns the action bound to the key pressed in event, or ActionNone.
+func (km *Keymap) Action(event *tcell.EventKey) Action {
+	return km.actions[KeyOf(event)]
+}
+
+// Keys returns the keys bound to action.
+func (km *Keymap) Keys(action Action) []Key {
+	return km.keys[action]
+}
@@ -0,0 +1,99 @@
+package keys
+
+import (
+	"testing"
+
+	"github.com/gdamore/tcell"
+)
+
+func TestParseKey(t *testing.T) {
+	cases := []struct {
+		name     string
+		event    *tcell.EventKey
+		expected string
+	}{
+		{"enter", tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone), "Enter"},
+		{"Ctrl-N", tcell.NewEventKey(tcell.KeyCtrlN, 0, tcell.ModCtrl), "Ctrl-N"},
+		// not every terminal reports the Ctrl modifier
+		{"ctrl-j", tcell.NewEventKey(tcell.KeyCtrlJ, 0, tcell.ModNone), "Ctrl-J"},
+		{"alt-p", tcell.NewEventKey(tcell.KeyRune, 'p', tcell.ModAlt), "Alt-P"},
+		{"alt-P", tcell.NewEventKey(tcell.KeyRune, 'P', tcell.ModAlt|tcell.ModShift), "Alt-Shift-P"},
+		{"shift-tab", tcell.NewEventKey(tcell.KeyBacktab, 0, tcell.ModShift), "Shift-Tab"},
+		{"alt-down", tcell.NewEventKey(tcell.KeyDown, 0, tcell.ModAlt), "Alt-Down"},
+		{"backspace", tcell.NewEventKey(tcell.KeyBackspace, 0, tcell.ModNone), "Backspace"},
+		{"?", tcell.NewEventKey(tcell.KeyRune, '?', tcell.ModNone), "?"},
+	}
+	for _, c := range cases {
+		key, err := ParseKey(c.name)
+		if err != nil {
+			t.Errorf("'%s': unexpected error %v", c.name, err)
+			continue
+		}
+		if event := KeyOf(c.event); key != event {
+			t.Errorf("'%s': parsed as %+v, event is %+v", c.name, key, event)
+		}
+		if key.String() != c.expected {
+			t.Errorf("'%s': expected '%s', got '%s'", c.name, c.expected, key.String())
+		}
+	}
+
+	for _, name := range []string{"", "ctrl-", "ctrl-1", "hyper-x", "enterr", "ctrl-h", "ctrl-I", "alt-ctrl-m"} {
+		if _, err := ParseKey(name); err == nil {
+			t.Errorf("'%s': expected an error", name)
+		}
+	}
+}
+
+func TestNewKeymap(t *testing.T) {
+	ctrlN := tcell.NewEventKey(tcell.KeyCtrlN, 0, tcell.ModCtrl)
+	ctrlP := tcell.NewEventKey(tcell.KeyCtrlP, 0, tcell.ModCtrl)
+	altP := tcell.NewEventKey(tcell.KeyRune, 'p', tcell.ModAlt)
+
+	km, err := NewKeymap("", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if action := km.Action(ctrlN); action != ActionNone {
+		t.Errorf("default: expected Ctrl-N to be unbound, got %s", action)
+	}
+	if action := km.Action(tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone)); action != ActionAccept {
+		t.Errorf("default: expected Enter to accept, got %s", action)
+	}
+
+	km, err = NewKeymap("emacs", Bindings{
+		ActionTogglePreview: {"ctrl-p"},
+		ActionToggleHelp:    {},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		event    *tcell.EventKey
+		expected Action
+	}{
+		{ctrlN, ActionSelectDown},
+		// taken from select-up by the user's binding
+		{ctrlP, ActionTogglePreview},
+		{altP, ActionNone},
+		{tcell.NewEventKey(tcell.KeyRune, 'h', tcell.ModAlt), ActionNone},
+		{tcell.NewEventKey(tcell.KeyUp, 0, tcell.ModNone), ActionSelectUp},
+	}
+	for _, c := range cases {
+		if action := km.Action(c.event); action != c.expected {
+			t.Errorf("%s: expected %s, got %s", KeyOf(c.event), c.expected, action)
+		}
+	}
+	if keys := km.Keys(ActionSelectUp); len(keys) != 1 {
+		t.Errorf("expected select-up to be left with one key, got %v", keys)
+	}
+
+	if _, err := NewKeymap("nano", nil); err == nil {
+		t.Error("expected error for unknown preset")
+	}
+	if _, err := NewKeymap("", Bindings{"fly": {"f"}}); err == nil {
+		t.Error("expected error for unknown action")
+	}
+	if _, err := NewKeymap("", Bindings{ActionCancel: {"ctrl-"}}); err == nil {
+		t.Error("expected error for invalid key")
+	}
+}
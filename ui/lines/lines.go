@@ -0,0 +1,155 @@
+// Package lines shows lines of text, e.g. read from stdin, in a table.Table,
+// split into columns at a delimiter.
+package lines
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/jwdevantier/spellbook/ui/table"
+	"github.com/lithammer/fuzzysearch/fuzzy"
+)
+
+// LineRow is a line of text, split into fields at the delimiter, if any.
+type LineRow struct {
+	// lines may repeat, rows are told apart by their line number
+	id     uint64
+	text   string
+	fields []string
+}
+
+func (lr *LineRow) Id() uint64 {
+	return lr.id
+}
+
+// Text returns the line, as read.
+func (lr *LineRow) Text() string {
+	return lr.text
+}
+
+// ReadLines reads the lines of r, without their line endings.
+func ReadLines(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	// allow long lines, e.g. minified JSON
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		lines = append(lines, strings.TrimSuffix(scanner.Text(), "\r"))
+	}
+	return lines, scanner.Err()
+}
+
+// ToRows returns a row for each line, split into fields at delimiter unless
+// it is "".
+func ToRows(lines []string, delimiter string) []table.Row {
+	rows := make([]table.Row, len(lines))
+	for i, line := range lines {
+		fields := []string{line}
+		if delimiter != "" {
+			fields = strings.Split(line, delimiter)
+		}
+		rows[i] = &LineRow{id: uint64(i), text: line, fields: fields}
+	}
+	return rows
+}
+
+// LineRenderer shows each field of a line in its own column.
+type LineRenderer struct {
+	columns []table.Column
+}
+
+// NewLineRenderer returns a renderer for rows of up to as many fields as
+// the widest of rows.
+func NewLineRenderer(rows []table.Row) *LineRenderer {
+	width := 1
+	for _, row := range rows {
+		if fields := len(row.(*LineRow).fields); fields > width {
+			width = fields
+		}
+	}
+	lr := &LineRenderer{}
+	for i := 0; i < width; i++ {
+		col := table.NewColumn(fmt.Sprint(i + 1))
+		col.MinWidth = 10
+		lr.columns = append(lr.columns, col)
+	}
+	// the last column takes up the width left
+	lr.columns[width-1].Expansion = 1
+	return lr
+}
+
+func (lr *LineRenderer) Columns() []table.Column {
+	return lr.columns
+}
+
+func (lr *LineRenderer) Render(row table.Row) []string {
+	lrow, ok := row.(*LineRow)
+	if !ok {
+		panic("Invalid renderer")
+	}
+	out := make([]string, len(lr.columns))
+	for i, field := range lrow.fields {
+		if i == len(out) {
+			break
+		}
+		// tabs are not drawn by the table
+		out[i] = strings.ReplaceAll(field, "\t", "  ")
+	}
+	return out
+}
+
+// LineFuzzyFilter keeps the lines matching the search, best matches first.
+type LineFuzzyFilter struct {
+	filterString string
+}
+
+type match struct {
+	rank int
+	row  *LineRow
+}
+
+type matches []match
+
+func (m matches) Len() int {
+	return len(m)
+}
+
+func (m matches) Less(i, j int) bool {
+	return m[i].rank < m[j].rank
+}
+
+func (m matches) Swap(i, j int) {
+	m[i], m[j] = m[j], m[i]
+}
+
+func (lf *LineFuzzyFilter) Filter(rows []table.Row) []table.Row {
+	if lf.filterString == "" {
+		return rows
+	}
+	m := make(matches, 0, len(rows))
+	for _, row := range rows {
+		lrow := row.(*LineRow)
+		if rank := fuzzy.RankMatchFold(lf.filterString, lrow.text); rank != -1 {
+			m = append(m, match{rank: rank, row: lrow})
+		}
+	}
+	// best matches, with the lowest rank, first
+	sort.Stable(m)
+
+	out := make([]table.Row, len(m))
+	for i, v := range m {
+		out[i] = v.row
+	}
+	return out
+}
+
+func (lf *LineFuzzyFilter) SetSearchString(s string) {
+	lf.filterString = s
+}
+
+func NewLineFuzzyFilter() *LineFuzzyFilter {
+	return &LineFuzzyFilter{}
+}
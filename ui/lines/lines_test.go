@@ -0,0 +1,49 @@
+package lines
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadLines(t *testing.T) {
+	read, err := ReadLines(strings.NewReader("a\tb\r\nc\n\nd"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"a\tb", "c", "", "d"}
+	if !reflect.DeepEqual(read, expected) {
+		t.Errorf("expected %q, got %q", expected, read)
+	}
+
+	rows := ToRows(read, "\t")
+	renderer := NewLineRenderer(rows)
+	if n := len(renderer.Columns()); n != 2 {
+		t.Fatalf("expected 2 columns, got %d", n)
+	}
+	if out := renderer.Render(rows[1]); !reflect.DeepEqual(out, []string{"c", ""}) {
+		t.Errorf("expected short lines to leave columns empty, got %q", out)
+	}
+	whole := ToRows(read, "")
+	if out := NewLineRenderer(whole).Render(whole[0]); out[0] != "a  b" {
+		t.Errorf("expected tabs to be shown as spaces, got %q", out)
+	}
+	if rows[0].(*LineRow).Text() != "a\tb" {
+		t.Errorf("expected the line as read, got %q", rows[0].(*LineRow).Text())
+	}
+}
+
+func TestLineFuzzyFilter(t *testing.T) {
+	rows := ToRows([]string{"src/main.go", "README.md", "src/Makefile", "src/main.go"}, "")
+	filter := NewLineFuzzyFilter()
+	filter.SetSearchString("ma")
+	var matched []string
+	for _, row := range filter.Filter(rows) {
+		matched = append(matched, row.(*LineRow).Text())
+	}
+	// case is ignored, repeated lines are kept and the best matches come first
+	expected := []string{"src/main.go", "src/main.go", "src/Makefile"}
+	if !reflect.DeepEqual(matched, expected) {
+		t.Errorf("expected %q, got %q", expected, matched)
+	}
+}
@@ -0,0 +1,17 @@
+package ui
+
+import (
+	"github.com/gdamore/tcell"
+	"github.com/rivo/tview"
+)
+
+// MouseHandler is the mouse handler of a tview.Primitive.
+type MouseHandler func(action tview.MouseAction, event *tcell.EventMouse, setFocus func(p tview.Primitive)) (consumed bool, capture tview.Primitive)
+
+// KeepFocus wraps the mouse handler of a widget which should not take the
+// focus when clicked, leaving it with the input field.
+func KeepFocus(handler MouseHandler) MouseHandler {
+	return func(action tview.MouseAction, event *tcell.EventMouse, setFocus func(p tview.Primitive)) (consumed bool, capture tview.Primitive) {
+		return handler(action, event, func(p tview.Primitive) {})
+	}
+}
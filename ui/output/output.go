@@ -0,0 +1,181 @@
+package output
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/gdamore/tcell"
+	"github.com/jwdevantier/spellbook/ui"
+	"github.com/jwdevantier/spellbook/utils"
+	"github.com/rivo/tview"
+)
+
+// Pane shows the output of commands run without leaving the UI.
+type Pane struct {
+	*tview.TextView
+	app *tview.Application
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+
+	// drawn is set once the pane has been laid out and drawn
+	drawn bool
+	// pending runs a job started before the pane was drawn, sized to the pane
+	pending func(cols int, rows int)
+}
+
+func NewPane(app *tview.Application) *Pane {
+	p := &Pane{
+		TextView: tview.NewTextView().
+			SetDynamicColors(true).
+			SetScrollable(true),
+		app: app,
+	}
+	p.SetBorder(true)
+	p.SetChangedFunc(func() {
+		app.Draw()
+	})
+	return p
+}
+
+// Running returns true while a command is running.
+func (p *Pane) Running() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.cancel != nil
+}
+
+// Cancel kills the running command, if any.
+func (p *Pane) Cancel() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.cancel != nil {
+		p.cancel()
+	}
+}
+
+// Run runs cmd in directory dir, replacing the contents of the pane with the
+// output of the command. onDone is called from the event loop once the
+// command has exited.
+func (p *Pane) Run(cmd string, dir string, onDone func(err error)) {
+	var err error
+	p.start(fmt.Sprintf("$ %s", cmd), func(ctx context.Context, out io.Writer, cols int, rows int) string {
+		err = utils.RunCaptured(ctx, cmd, dir, out, cols, rows)
+		p.printError(out, err)
+		return fmt.Sprintf("exit %d", utils.ExitCode(err))
+	}, func() {
+		if onDone != nil {
+			onDone(err)
+		}
+	})
+}
+
+// RunSteps runs the steps of a multi-step command in directory dir, replacing
+// the contents of the pane with their output. Unless keepGoing is true, the
+// remaining steps are skipped once a step fails. onDone is called from the
+// event loop once all steps are done.
+func (p *Pane) RunSteps(cmds []string, dir string, keepGoing bool, onDone func(results []utils.StepResult)) {
+	var results []utils.StepResult
+	title := fmt.Sprintf("%d steps", len(cmds))
+	p.start(title, func(ctx context.Context, out io.Writer, cols int, rows int) string {
+		results = utils.RunSteps(cmds, keepGoing, func(i int, cmd string) error {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			fmt.Fprintf(out, "[%d/%d] $ %s\n", i+1, len(cmds), cmd)
+			err := utils.RunCaptured(ctx, cmd, dir, out, cols, rows)
+			p.printError(out, err)
+			fmt.Fprintf(out, "[%d/%d] exit %d\n\n", i+1, len(cmds), utils.ExitCode(err))
+			return err
+		})
+		return fmt.Sprintf("exit %d", utils.StepsExitCode(results))
+	}, func() {
+		if onDone != nil {
+			onDone(results)
+		}
+	})
+}
+
+// start runs job in the background, replacing the contents of the pane with
+// its output. The status returned by job is shown in the title once done.
+func (p *Pane) start(title string, job func(ctx context.Context, out io.Writer, cols int, rows int) string, onDone func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	p.mu.Lock()
+	p.cancel = cancel
+	p.mu.Unlock()
+
+	p.Clear()
+	p.SetTitle(fmt.Sprintf(" %s ", tview.Escape(title)))
+	run := func(cols int, rows int) {
+		go func() {
+			status := job(ctx, tview.ANSIWriter(p), cols, rows)
+			cancel()
+			p.app.QueueUpdateDraw(func() {
+				p.mu.Lock()
+				p.cancel = nil
+				p.mu.Unlock()
+
+				p.SetTitle(fmt.Sprintf(" %s [%s] ", tview.Escape(title), status))
+				p.ScrollToEnd()
+				onDone()
+			})
+		}()
+	}
+	if !p.drawn {
+		// the pane is only sized once drawn, e.g. as it is shown for the first run
+		p.pending = run
+		return
+	}
+	_, _, width, height := p.GetInnerRect()
+	run(width, height)
+}
+
+// Draw draws the pane, then starts the job waiting for the pane to be sized.
+func (p *Pane) Draw(screen tcell.Screen) {
+	p.TextView.Draw(screen)
+	p.drawn = true
+	if p.pending != nil {
+		_, _, width, height := p.GetInnerRect()
+		run := p.pending
+		p.pending = nil
+		run(width, height)
+	}
+}
+
+// printError writes errors other than the command exiting with an error code to out
+func (p *Pane) printError(out io.Writer, err error) {
+	if _, ok := err.(interface{ ExitCode() int }); err != nil && !ok {
+		fmt.Fprintf(out, "%s\n", err)
+	}
+}
+
+// Show replaces the contents of the pane with text, e.g. the output of an earlier run.
+func (p *Pane) Show(title string, text string) {
+	p.Clear()
+	p.SetTitle(fmt.Sprintf(" %s ", tview.Escape(title)))
+	fmt.Fprint(p, tview.Escape(text))
+	p.ScrollToEnd()
+}
+
+// ScrollBy scrolls the output by the given number of lines, negative values scroll up.
+func (p *Pane) ScrollBy(lines int) {
+	row, col := p.GetScrollOffset()
+	if row += lines; row < 0 {
+		row = 0
+	}
+	p.ScrollTo(row, col)
+}
+
+func (p *Pane) Style(theme *ui.Base16Theme) {
+	p.SetBackgroundColor(theme.Background)
+	p.SetTextColor(theme.Foreground)
+	p.SetBorderColor(theme.Cyan)
+	p.SetTitleColor(theme.BrightCyan)
+}
+
+// MouseHandler scrolls the output with the mouse wheel, leaving the focus with the input field.
+func (p *Pane) MouseHandler() func(action tview.MouseAction, event *tcell.EventMouse, setFocus func(p tview.Primitive)) (consumed bool, capture tview.Primitive) {
+	return ui.KeepFocus(p.TextView.MouseHandler())
+}
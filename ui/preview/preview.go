@@ -0,0 +1,210 @@
+package preview
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell"
+	"github.com/jwdevantier/spellbook/ui"
+	"github.com/jwdevantier/spellbook/utils"
+	"github.com/rivo/tview"
+)
+
+// number of lines of help shown for the command's binary
+const helpLines = 15
+
+// Preview shows the details of a command: the full command, its description
+// and variables, where it is defined and when it was last run.
+type Preview struct {
+	*tview.TextView
+	app *tview.Application
+
+	command *utils.Command
+	last    *utils.HistoryEntry
+
+	// showHelp shows the man page of the command's binary
+	showHelp bool
+	// help by binary, "" while it is being looked up
+	help map[string]string
+
+	colorLabel    tcell.Color
+	colorVariable tcell.Color
+	attrVariable  string
+}
+
+func NewPreview(app *tview.Application) *Preview {
+	p := &Preview{
+		TextView: tview.NewTextView().
+			SetDynamicColors(true).
+			SetWrap(true).
+			SetWordWrap(true),
+		app:  app,
+		help: make(map[string]string),
+
+		colorLabel:    tcell.ColorDarkCyan,
+		colorVariable: tcell.ColorOrange,
+	}
+	p.SetBorder(true).SetTitle(" preview ")
+	return p
+}
+
+// SetCommand shows command, which was last run as described by last.
+// A nil last means the command has not been run before.
+func (p *Preview) SetCommand(command *utils.Command, last *utils.HistoryEntry) {
+	p.command = command
+	p.last = last
+	p.Render()
+}
+
+// SetShowHelp sets whether the help of the command's binary is shown.
+func (p *Preview) SetShowHelp(show bool) {
+	p.showHelp = show
+	p.Render()
+}
+
+func (p *Preview) ShowHelp() bool {
+	return p.showHelp
+}
+
+func (p *Preview) Render() {
+	p.Clear()
+	p.ScrollToBeginning()
+	if p.command == nil {
+		return
+	}
+	c := p.command
+	var b strings.Builder
+
+	for i, template := range c.Templates() {
+		if len(c.Steps) != 0 {
+			fmt.Fprintf(&b, "%d. ", i+1)
+		}
+		b.WriteString(p.highlight(template))
+		b.WriteString("\n")
+	}
+	if c.Desc != "" {
+		fmt.Fprintf(&b, "\n%s\n", tview.Escape(c.Desc))
+	}
+
+	if names, err := c.VarNames(); err == nil && len(names) != 0 {
+		b.WriteString("\n" + p.label("Variables") + "\n")
+		for _, name := range names {
+			fmt.Fprintf(&b, "  %s  %s\n", p.variable(name), tview.Escape(c.Vars[name]))
+		}
+	}
+
+	b.WriteString("\n")
+	if c.SourceName != "" {
+		p.field(&b, "Source", fmt.Sprintf("%s (%s)", c.Source, c.SourceName))
+	} else {
+		p.field(&b, "Source", c.Source)
+	}
+	if dir, err := c.WorkDir(); err != nil {
+		p.field(&b, "Cwd", err.Error())
+	} else if dir != "" {
+		p.field(&b, "Cwd", dir)
+	}
+	if c.Mode != "" {
+		p.field(&b, "Mode", c.Mode)
+	}
+	if p.last == nil {
+		p.field(&b, "Last run", "never")
+	} else {
+		exit := fmt.Sprintf("exit %d", p.last.ExitCode)
+		if p.last.ExitCode == utils.ExitCodeUnknown {
+			exit = "exec"
+		}
+		runs := fmt.Sprintf("%d runs", p.last.Runs)
+		if p.last.Runs == 1 {
+			runs = "1 run"
+		}
+		p.field(&b, "Last run", fmt.Sprintf("%s (%s, %s)",
+			p.last.LastRun.Format("2006-01-02 15:04"), exit, runs))
+	}
+
+	if p.showHelp {
+		b.WriteString("\n" + p.label("Help") + "\n")
+		b.WriteString(tview.Escape(p.lookupHelp()))
+		b.WriteString("\n")
+	}
+	p.SetText(b.String())
+}
+
+// lookupHelp returns the help of the command's binary, looking it up in the
+// background if it is not known yet.
+func (p *Preview) lookupHelp() string {
+	binary, ok := utils.Binary(p.command.Templates()[0])
+	if !ok {
+		return "unknown program"
+	}
+	if help, found := p.help[binary]; found {
+		if help == "" {
+			return "loading..."
+		}
+		return help
+	}
+	p.help[binary] = ""
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		help, err := utils.Help(ctx, binary, helpLines)
+		if err != nil {
+			help = fmt.Sprintf("no help for %s: %v", binary, err)
+		}
+		p.app.QueueUpdateDraw(func() {
+			p.help[binary] = help
+			p.Render()
+		})
+	}()
+	return "loading..."
+}
+
+// highlight returns template with its variables highlighted.
+func (p *Preview) highlight(template string) string {
+	toks, err := utils.ParseCmd(template)
+	if err != nil {
+		return tview.Escape(template)
+	}
+	var b strings.Builder
+	for _, tok := range toks {
+		if tok.Type == utils.TokVar {
+			b.WriteString(p.variable("%(" + tok.Lexeme + ")"))
+		} else {
+			b.WriteString(tview.Escape(tok.Lexeme))
+		}
+	}
+	return b.String()
+}
+
+func (p *Preview) variable(text string) string {
+	return fmt.Sprintf("[%s::%s]%s[-::-]", ui.ColorTag(p.colorVariable), p.attrVariable, tview.Escape(text))
+}
+
+func (p *Preview) label(text string) string {
+	return fmt.Sprintf("[%s]%s[-]", ui.ColorTag(p.colorLabel), tview.Escape(text))
+}
+
+func (p *Preview) field(b *strings.Builder, label string, value string) {
+	fmt.Fprintf(b, "%s %s\n", p.label(fmt.Sprintf("%-9s", label)), tview.Escape(value))
+}
+
+func (p *Preview) Style(theme *ui.Base16Theme) {
+	p.SetBackgroundColor(theme.Background)
+	p.SetTextColor(theme.Foreground)
+	p.SetBorderColor(theme.Cyan)
+	p.SetTitleColor(theme.Foreground)
+	p.colorLabel = theme.Cyan
+	p.colorVariable = theme.BrightGreen
+	p.attrVariable = ""
+	if theme.Monochrome {
+		p.attrVariable = "u"
+	}
+	p.Render()
+}
+
+// MouseHandler scrolls the preview with the mouse wheel, leaving the focus with the input field.
+func (p *Preview) MouseHandler() func(action tview.MouseAction, event *tcell.EventMouse, setFocus func(p tview.Primitive)) (consumed bool, capture tview.Primitive) {
+	return ui.KeepFocus(p.TextView.MouseHandler())
+}
@@ -0,0 +1,204 @@
+package statusbar
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gdamore/tcell"
+	"github.com/jwdevantier/spellbook/ui"
+	"github.com/rivo/tview"
+)
+
+// Mode is what the user is doing, shown at the start of the status bar.
+type Mode string
+
+const (
+	ModeSearch     Mode = "search"
+	ModeCompletion Mode = "completion"
+	ModeChecklist  Mode = "checklist"
+)
+
+// StatusBar is a single line showing the state of the UI: the mode, how many
+// commands match the search, the configs loaded, the variable being
+// completed, and hints for the keys which apply.
+type StatusBar struct {
+	*tview.Table
+
+	mode             Mode
+	matched, total   int
+	marked           int
+	sources          []string
+	varName, varDesc string
+	context          string
+	message          string
+	hints            string
+
+	colorText    tcell.Color
+	colorMode    tcell.Color
+	colorVar     tcell.Color
+	colorMessage tcell.Color
+	colorHints   tcell.Color
+	attrVar      tcell.AttrMask
+}
+
+func NewStatusBar() *StatusBar {
+	s := &StatusBar{
+		Table: tview.NewTable().SetSelectable(false, false),
+		mode:  ModeSearch,
+
+		colorText:    tcell.ColorWhite,
+		colorMode:    tcell.ColorDarkCyan,
+		colorVar:     tcell.ColorOrange,
+		colorMessage: tcell.ColorRed,
+		colorHints:   tcell.ColorGray,
+	}
+	return s
+}
+
+// SetMode sets the mode shown, clearing the variable, context and message
+// of the previous mode.
+func (s *StatusBar) SetMode(mode Mode) *StatusBar {
+	s.mode = mode
+	s.varName, s.varDesc = "", ""
+	s.context = ""
+	s.message = ""
+	return s
+}
+
+func (s *StatusBar) Mode() Mode {
+	return s.mode
+}
+
+// SetCounts sets the number of commands matching the search, out of total.
+func (s *StatusBar) SetCounts(matched, total int) *StatusBar {
+	s.matched, s.total = matched, total
+	return s
+}
+
+// SetMarked sets the number of commands marked, shown if any are.
+func (s *StatusBar) SetMarked(marked int) *StatusBar {
+	s.marked = marked
+	return s
+}
+
+// SetSources sets the labels of the config files loaded, e.g. "global".
+func (s *StatusBar) SetSources(sources []string) *StatusBar {
+	s.sources = sources
+	return s
+}
+
+// SetVariable sets the variable being completed and its description.
+// An empty name means no variable is being completed.
+func (s *StatusBar) SetVariable(name, desc string) *StatusBar {
+	s.varName, s.varDesc = name, desc
+	return s
+}
+
+// Variable returns the name of the variable shown, if any.
+func (s *StatusBar) Variable() string {
+	return s.varName
+}
+
+// SetContext sets information about the mode, e.g. the working directory
+// of the command being completed.
+func (s *StatusBar) SetContext(context string) *StatusBar {
+	s.context = context
+	return s
+}
+
+// SetMessage shows message, e.g. an error, until the mode changes or the
+// message is cleared with an empty message.
+func (s *StatusBar) SetMessage(message string) *StatusBar {
+	s.message = message
+	return s
+}
+
+// ClearMessage clears message, if it is the message shown.
+func (s *StatusBar) ClearMessage(message string) *StatusBar {
+	if s.message == message {
+		s.message = ""
+	}
+	return s
+}
+
+// hintSeparator separates the key hints, e.g. "Tab: next  Esc: back"
+const hintSeparator = "  "
+
+// SetHints sets the key hints shown at the end of the status bar, most
+// important first, separated by two spaces.
+func (s *StatusBar) SetHints(hints string) *StatusBar {
+	s.hints = hints
+	return s
+}
+
+// Draw lays out the status bar for the width available, leaving out the key
+// hints there is no room for.
+func (s *StatusBar) Draw(screen tcell.Screen) {
+	_, _, width, _ := s.GetInnerRect()
+	s.render(width)
+	s.Table.Draw(screen)
+}
+
+func (s *StatusBar) render(width int) {
+	s.Clear()
+	col, used := 0, 0
+	add := func(text string, color tcell.Color) *tview.TableCell {
+		text = tview.Escape(text + " ")
+		cell := tview.NewTableCell(text).SetTextColor(color)
+		s.SetCell(0, col, cell)
+		col++
+		used += tview.TaggedStringWidth(text) + 1 // columns are one cell apart
+		return cell
+	}
+	add(fmt.Sprintf("[%s]", s.mode), s.colorMode)
+	if s.mode == ModeSearch {
+		add(fmt.Sprintf("%d/%d", s.matched, s.total), s.colorText)
+		if s.marked != 0 {
+			add(fmt.Sprintf("%d marked", s.marked), s.colorVar)
+		}
+		if len(s.sources) != 0 {
+			add(strings.Join(s.sources, "+"), s.colorText)
+		}
+	}
+	if s.varName != "" {
+		add(s.varName, s.colorVar).SetAttributes(s.attrVar)
+		if s.varDesc != "" {
+			add(s.varDesc, s.colorText)
+		}
+	}
+	if s.message != "" {
+		add(s.message, s.colorMessage)
+	} else if s.context != "" {
+		add(s.context, s.colorText)
+	}
+	// leave out the last hints until the rest fit
+	hints := strings.Split(s.hints, hintSeparator)
+	for len(hints) != 0 {
+		text := tview.Escape(strings.Join(hints, hintSeparator))
+		if used+tview.TaggedStringWidth(text) <= width {
+			s.SetCell(0, col, tview.NewTableCell(text).
+				SetTextColor(s.colorHints).SetExpansion(1).SetAlign(tview.AlignRight))
+			break
+		}
+		hints = hints[:len(hints)-1]
+	}
+}
+
+func (s *StatusBar) Style(theme *ui.Base16Theme) {
+	s.SetBackgroundColor(theme.Background)
+	s.colorText = theme.Foreground
+	s.colorMode = theme.Cyan
+	s.colorVar = theme.BrightGreen
+	s.colorMessage = theme.Red
+	s.colorHints = theme.BrightBlack
+	if theme.Monochrome {
+		s.attrVar = tcell.AttrUnderline
+	} else {
+		s.attrVar = 0
+	}
+}
+
+// MouseHandler ignores clicks, leaving the focus with the input field.
+func (s *StatusBar) MouseHandler() func(action tview.MouseAction, event *tcell.EventMouse, setFocus func(p tview.Primitive)) (consumed bool, capture tview.Primitive) {
+	return ui.KeepFocus(s.Table.MouseHandler())
+}
@@ -2,11 +2,19 @@ package suggestions
 
 import (
 	"fmt"
+	"github.com/gdamore/tcell"
+	"github.com/jwdevantier/spellbook/ui"
 	"github.com/jwdevantier/spellbook/ui/table"
 	"github.com/jwdevantier/spellbook/utils"
 	"github.com/lithammer/fuzzysearch/fuzzy"
+	"github.com/mitchellh/go-homedir"
+	"github.com/rivo/tview"
 	"hash/fnv"
+	"os"
+	"path/filepath"
 	"sort"
+	"strings"
+	"time"
 )
 
 func hash(s string) uint64 {
@@ -21,63 +29,175 @@ func hash(s string) uint64 {
 type CommandRow struct {
 	id uint64
 	command utils.Command
+	// history of runs, for the last-used column. May be nil.
+	history *utils.History
 }
 
 func (cr *CommandRow) Id() uint64 {
 	return cr.id
 }
 
-func (cr *CommandRow) Len() int {
-	return 2 // Cmd: 0, Desc: 1
-}
-
 func (cr *CommandRow) Command() *utils.Command {
 	return &cr.command
 }
 
-func (cr *CommandRow) CellValue(col int) interface{} {
-	switch col {
-	case 0:
-		return cr.command.Cmd
-	case 1:
+// Field returns the value shown in the column called name, see Columns.
+func (cr *CommandRow) Field(name string) string {
+	switch name {
+	case ColumnCmd:
+		return cr.command.Text()
+	case ColumnDesc:
 		return cr.command.Desc
+	case ColumnTags:
+		return strings.Join(cr.command.Tags, ",")
+	case ColumnSource:
+		return shortPath(filepath.Dir(cr.command.Source))
+	case ColumnLastUsed:
+		if cr.history == nil {
+			return ""
+		}
+		if last, found := cr.history.Last(&cr.command); found {
+			return formatLastUsed(last.LastRun, time.Now())
+		}
+		return ""
 	default:
-		panic(fmt.Sprintf("out of range! [0-%d[, got: %d", cr.Len(), col))
+		panic(fmt.Sprintf("unknown column '%s'", name))
 	}
-	return cr.command
 }
 
-func NewCommandRow(command utils.Command) table.Row {
+func NewCommandRow(command utils.Command, history *utils.History) table.Row {
 	return &CommandRow{
-		id:      hash(command.Cmd),
+		// the same command may come from several sources, e.g. a config file and a Makefile
+		id:      hash(command.Source + "\x00" + command.Text()),
 		command: command,
+		history: history,
 	}
 }
 
-func ToRowsCommands(commands []utils.Command) []table.Row {
+func ToRowsCommands(commands []utils.Command, history *utils.History) []table.Row {
 	out := make([]table.Row, len(commands))
 	for i, command := range commands {
-		out[i] = NewCommandRow(command)
+		out[i] = NewCommandRow(command, history)
 	}
 	return out
 }
 
+// shortPath returns path relative to the working directory if it is inside
+// it, or else with the home directory abbreviated to ~
+func shortPath(path string) string {
+	if wd, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(wd, path); err == nil && !strings.HasPrefix(rel, "..") {
+			return rel
+		}
+	}
+	if home, err := homedir.Dir(); err == nil && home != "" {
+		if rel, err := filepath.Rel(home, path); err == nil && !strings.HasPrefix(rel, "..") {
+			return filepath.Join("~", rel)
+		}
+	}
+	return path
+}
+
+// formatLastUsed formats t relative to now: the time of day for today, the
+// date otherwise.
+func formatLastUsed(t time.Time, now time.Time) string {
+	y, m, d := t.Date()
+	ny, nm, nd := now.Date()
+	switch {
+	case y == ny && m == nm && d == nd:
+		return t.Format("15:04")
+	case y == ny:
+		return t.Format("Jan 02")
+	default:
+		return t.Format("Jan 2006")
+	}
+}
+
 // Command Renderers
 //
-type CommandRenderer struct {}
+
+// names of the columns a CommandRenderer can show
+const (
+	ColumnCmd      = "cmd"
+	ColumnDesc     = "desc"
+	ColumnTags     = "tags"
+	ColumnSource   = "source"
+	ColumnLastUsed = "last-used"
+)
+
+// DefaultColumns are the columns shown unless configured otherwise
+var DefaultColumns = []string{ColumnCmd, ColumnDesc}
+
+// commandColumns lays out each column, colors are set by Style
+var commandColumns = map[string]table.Column{
+	// leave room for the description of long commands
+	ColumnCmd:      {Name: ColumnCmd, MinWidth: 20, MaxRatio: 0.6, Align: tview.AlignLeft, Color: tcell.ColorDefault},
+	ColumnDesc:     {Name: ColumnDesc, MinWidth: 10, Expansion: 1, Align: tview.AlignLeft, Color: tcell.ColorDefault},
+	ColumnTags:     {Name: ColumnTags, MaxRatio: 0.2, Align: tview.AlignLeft, Color: tcell.ColorDefault},
+	ColumnSource:   {Name: ColumnSource, MaxRatio: 0.2, Align: tview.AlignLeft, Color: tcell.ColorDefault},
+	ColumnLastUsed: {Name: ColumnLastUsed, Align: tview.AlignRight, Color: tcell.ColorDefault},
+}
+
+// ColumnError is returned for unknown column names
+type ColumnError struct {
+	Name string
+}
+
+func (e *ColumnError) Error() string {
+	names := make([]string, 0, len(commandColumns))
+	for name := range commandColumns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return fmt.Sprintf("unknown column '%s', expected one of %s", e.Name, strings.Join(names, ", "))
+}
+
+type CommandRenderer struct {
+	columns []table.Column
+}
+
+func (cr *CommandRenderer) Columns() []table.Column {
+	return cr.columns
+}
+
 func (cr *CommandRenderer) Render(row table.Row) []string {
 	crow, ok := row.(*CommandRow)
 	if !ok {
 		panic("Invalid renderer")
 	}
-	return []string{
-		crow.command.Cmd + "    ", // Poor man's padding
-		crow.command.Desc,
+	out := make([]string, len(cr.columns))
+	for i, col := range cr.columns {
+		out[i] = crow.Field(col.Name)
 	}
+	return out
 }
 
-func NewCommandRenderer() *CommandRenderer {
-	return &CommandRenderer{}
+func (cr *CommandRenderer) Style(theme *ui.Base16Theme) {
+	for i := range cr.columns {
+		switch cr.columns[i].Name {
+		case ColumnTags:
+			cr.columns[i].Color = theme.Magenta
+		case ColumnSource, ColumnLastUsed:
+			cr.columns[i].Color = theme.BrightBlack
+		}
+	}
+}
+
+// NewCommandRenderer returns a renderer showing the named columns, or
+// DefaultColumns if none are given.
+func NewCommandRenderer(names ...string) (*CommandRenderer, error) {
+	if len(names) == 0 {
+		names = DefaultColumns
+	}
+	cr := &CommandRenderer{}
+	for _, name := range names {
+		col, found := commandColumns[name]
+		if !found {
+			return nil, &ColumnError{name}
+		}
+		cr.columns = append(cr.columns, col)
+	}
+	return cr, nil
 }
 
 // Command Filter
@@ -115,7 +235,7 @@ func (cf *CommandFuzzyFilter) Filter(rows []table.Row) []table.Row {
 	for i, row := range rows {
 		crow := row.(*CommandRow)
 		m[i] = match{
-			rank: fuzzy.RankMatch(cf.filterString, crow.command.Cmd),
+			rank: fuzzy.RankMatch(cf.filterString, crow.command.Text()),
 			row: crow}
 	}
 	sort.Sort(sort.Reverse(m))
@@ -0,0 +1,39 @@
+package suggestions
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jwdevantier/spellbook/utils"
+)
+
+func TestCommandRenderer(t *testing.T) {
+	if _, err := NewCommandRenderer(ColumnCmd, "bogus"); err == nil {
+		t.Error("expected error for unknown column")
+	}
+
+	renderer, err := NewCommandRenderer(ColumnDesc, ColumnTags, ColumnCmd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	row := NewCommandRow(utils.Command{Cmd: "make %(target)", Desc: "build", Tags: []string{"make", "c"}}, nil)
+	expected := []string{"build", "make,c", "make %(target)"}
+	if out := renderer.Render(row); !reflect.DeepEqual(out, expected) {
+		t.Errorf("expected %v, got %v", expected, out)
+	}
+}
+
+func TestFormatLastUsed(t *testing.T) {
+	now := time.Date(2020, 5, 17, 18, 0, 0, 0, time.UTC)
+	cases := map[time.Time]string{
+		time.Date(2020, 5, 17, 9, 30, 0, 0, time.UTC): "09:30",
+		time.Date(2020, 3, 2, 9, 30, 0, 0, time.UTC):  "Mar 02",
+		time.Date(2019, 3, 2, 9, 30, 0, 0, time.UTC):  "Mar 2019",
+	}
+	for t0, expected := range cases {
+		if out := formatLastUsed(t0, now); out != expected {
+			t.Errorf("expected '%s', got '%s'", expected, out)
+		}
+	}
+}
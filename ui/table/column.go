@@ -0,0 +1,86 @@
+package table
+
+import (
+	"github.com/gdamore/tcell"
+	"github.com/jwdevantier/spellbook/ui"
+	"github.com/rivo/tview"
+)
+
+// Column describes how a column rendered by a Renderer is laid out.
+type Column struct {
+	Name string
+	// MinWidth is the width below which the column is not shrunk to make
+	// room for other columns
+	MinWidth int
+	// MaxWidth is the widest the column may be, 0 for no limit
+	MaxWidth int
+	// MaxRatio is the largest share of the table's width the column may
+	// take, e.g. 0.5 for half of it. 0 for no limit.
+	MaxRatio float64
+	// Expansion is the share of any unused width given to the column, see
+	// tview.TableCell.SetExpansion
+	Expansion int
+	// Align is tview.AlignLeft, tview.AlignCenter or tview.AlignRight
+	Align int
+	// Color of the text, tcell.ColorDefault for the table's text color
+	Color tcell.Color
+}
+
+// NewColumn returns a left-aligned column with the table's text color,
+// which shrinks as needed.
+func NewColumn(name string) Column {
+	return Column{
+		Name:  name,
+		Align: tview.AlignLeft,
+		Color: tcell.ColorDefault,
+	}
+}
+
+// StyledRenderer is implemented by renderers whose columns take their
+// colors from the theme.
+type StyledRenderer interface {
+	Renderer
+	Style(theme *ui.Base16Theme)
+}
+
+// fitColumns returns the width of each column, given the width of its
+// widest cell, such that the columns fit in width. Cells are one cell apart.
+// Columns wider than their MaxWidth or MaxRatio are truncated, after which
+// the widest columns are shrunk first, down to their MinWidth.
+func fitColumns(columns []Column, natural []int, width int) []int {
+	widths := make([]int, len(columns))
+	total := 0
+	for i, col := range columns {
+		w := natural[i]
+		if col.MaxWidth > 0 && w > col.MaxWidth {
+			w = col.MaxWidth
+		}
+		if col.MaxRatio > 0 {
+			limit := int(col.MaxRatio * float64(width))
+			if limit < col.MinWidth {
+				limit = col.MinWidth
+			}
+			if w > limit {
+				w = limit
+			}
+		}
+		widths[i] = w
+		total += w
+	}
+
+	available := width - (len(columns) - 1)
+	for total > available {
+		widest := -1
+		for i, w := range widths {
+			if w > columns[i].MinWidth && w > 1 && (widest == -1 || w > widths[widest]) {
+				widest = i
+			}
+		}
+		if widest == -1 {
+			break // nothing left to shrink
+		}
+		widths[widest]--
+		total--
+	}
+	return widths
+}
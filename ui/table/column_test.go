@@ -0,0 +1,35 @@
+package table
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFitColumns(t *testing.T) {
+	cmd := NewColumn("cmd")
+	cmd.MaxRatio = 0.5
+	desc := NewColumn("desc")
+	desc.MinWidth = 10
+	source := NewColumn("source")
+	source.MaxWidth = 8
+
+	cases := []struct {
+		natural  []int
+		width    int
+		expected []int
+	}{
+		// everything fits
+		{[]int{20, 15, 6}, 100, []int{20, 15, 6}},
+		// limited by MaxRatio and MaxWidth
+		{[]int{80, 15, 20}, 100, []int{50, 15, 8}},
+		// widest columns shrink first, desc not below its MinWidth
+		{[]int{30, 30, 8}, 50, []int{20, 20, 8}},
+		{[]int{30, 30, 8}, 30, []int{10, 10, 8}},
+	}
+	for _, c := range cases {
+		widths := fitColumns([]Column{cmd, desc, source}, c.natural, c.width)
+		if !reflect.DeepEqual(widths, c.expected) {
+			t.Errorf("natural %v in %d: expected %v, got %v", c.natural, c.width, c.expected, widths)
+		}
+	}
+}
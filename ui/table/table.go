@@ -9,13 +9,12 @@ import (
 type Row interface {
 	// Return unique id for row
 	Id() uint64
-	// Number of columns
-	Len() int
-	// value in cell
-	CellValue(col int) interface{}
 }
 
 type Renderer interface {
+	// Columns describes the columns rendered
+	Columns() []Column
+	// Render returns the text of each column for row
 	Render(Row) []string
 }
 
@@ -70,14 +69,120 @@ func NewTableModel(rows []Row) *Model {
 type Table struct {
 	model    *Model
 	filter   Filter
-	view     *tview.Table
+	view     *view
 	renderer Renderer
-	onTab    func()
-	onEsc    func()
+	onRendered func(matched, total int)
+	onDoubleClick func()
+	// wrapAround moves the selection from the last row to the first, and back
+	wrapAround bool
+	// markable rows can be marked, to pick several at once
+	markable bool
+	// ids of the marked rows
+	marks map[uint64]bool
+
+	textColor tcell.Color
+	backgroundColor tcell.Color
+	scrollBarColor tcell.Color
+	markColor tcell.Color
 
 	rowIndex map[int]uint64
 }
 
+// view is the tview.Table showing the rows, laying out the columns to fit
+// the width available before each draw.
+type view struct {
+	*tview.Table
+	table *Table
+}
+
+func (v *view) Draw(screen tcell.Screen) {
+	// the table has no border, its inner rect is its rect
+	x, y, width, height := v.GetRect()
+	if v.GetRowCount() <= height {
+		v.table.layout(width)
+		v.Table.Draw(screen)
+		return
+	}
+	// leave room for the scroll bar
+	v.SetRect(x, y, width-1, height)
+	v.table.layout(width - 1)
+	v.Table.Draw(screen)
+	v.SetRect(x, y, width, height)
+	v.drawScrollBar(screen, x+width-1, y, height)
+}
+
+// drawScrollBar draws the position of the visible rows among all rows as a
+// bar height rows high at x, y.
+func (v *view) drawScrollBar(screen tcell.Screen, x, y, height int) {
+	rows := v.GetRowCount()
+	offset, _ := v.GetOffset()
+	size := height * height / rows
+	if size < 1 {
+		size = 1
+	}
+	start := offset * height / rows
+	if start+size > height || offset+height >= rows {
+		start = height - size
+	}
+	style := tcell.StyleDefault.Background(v.table.backgroundColor)
+	for i := 0; i < height; i++ {
+		if i >= start && i < start+size {
+			screen.SetContent(x, y+i, '█', nil, style.Foreground(v.table.textColor))
+		} else {
+			screen.SetContent(x, y+i, tview.Borders.Vertical, nil, style.Foreground(v.table.scrollBarColor))
+		}
+	}
+}
+
+// MouseHandler selects the row clicked, calling the double click handler
+// if the row is double clicked, and moves the selection with the mouse
+// wheel. The focus is left where it is.
+func (v *view) MouseHandler() func(action tview.MouseAction, event *tcell.EventMouse, setFocus func(p tview.Primitive)) (consumed bool, capture tview.Primitive) {
+	return v.WrapMouseHandler(func(action tview.MouseAction, event *tcell.EventMouse, setFocus func(p tview.Primitive)) (consumed bool, capture tview.Primitive) {
+		x, y := event.Position()
+		if !v.InRect(x, y) {
+			return false, nil
+		}
+		switch action {
+		case tview.MouseLeftClick:
+			v.table.selectAt(y)
+		case tview.MouseLeftDoubleClick:
+			if v.table.selectAt(y) && v.table.onDoubleClick != nil {
+				v.table.onDoubleClick()
+			}
+		case tview.MouseScrollUp:
+			v.table.moveSelection(-1, false)
+		case tview.MouseScrollDown:
+			v.table.moveSelection(1, false)
+		}
+		return true, nil
+	})
+}
+
+// layout sets the maximum width of each column's cells to fit width.
+func (t *Table) layout(width int) {
+	columns := t.renderer.Columns()
+	natural := make([]int, len(columns))
+	for r := 0; r < t.view.GetRowCount(); r++ {
+		for c := range columns {
+			if cell := t.view.GetCell(r, c); cell != nil {
+				if w := tview.TaggedStringWidth(cell.Text); w > natural[c] {
+					natural[c] = w
+				}
+			}
+		}
+	}
+	widths := fitColumns(columns, natural, width)
+	for r := 0; r < t.view.GetRowCount(); r++ {
+		for c, w := range widths {
+			if w == 0 {
+				w = 1 // 0 would mean no limit
+			}
+			t.view.GetCell(r, c).SetMaxWidth(w)
+		}
+	}
+}
+
 func (t *Table) Model() *Model {
 	return t.model
 }
@@ -97,26 +202,71 @@ func (t *Table) SetOnSelected(handler func (cell *tview.TableCell)) *Table {
 	return t
 }
 
-func (t *Table) SetOnTab(handler func()) *Table {
-	t.onTab = handler
+// SetOnSelectionChanged sets the handler called when another row is
+// selected. row is nil if no row is selected.
+func (t *Table) SetOnSelectionChanged(handler func(row Row)) *Table {
+	t.view.SetSelectionChangedFunc(func(r, column int) {
+		row, _ := t.GetSelectedRow()
+		handler(row)
+	})
+	return t
+}
+
+// SetOnDoubleClick sets the handler called when a row is double clicked,
+// after it is selected.
+func (t *Table) SetOnDoubleClick(handler func()) *Table {
+	t.onDoubleClick = handler
+	return t
+}
+
+// SetWrapAround sets whether moving the selection down from the last row
+// selects the first row, and up from the first row the last.
+func (t *Table) SetWrapAround(wrapAround bool) *Table {
+	t.wrapAround = wrapAround
 	return t
 }
 
-func (t *Table) SetOnEsc(handler func()) *Table {
-	t.onEsc = handler
+// SetOnRendered sets the handler called after each render with the number
+// of rows passing the filter, out of the total number of rows.
+func (t *Table) SetOnRendered(handler func(matched, total int)) *Table {
+	t.onRendered = handler
 	return t
 }
 
 func (t *Table) SetBackgroundColor(color tcell.Color) *Table {
+	t.backgroundColor = color
 	t.view.SetBackgroundColor(color)
 	return t
 }
 
+// SetScrollBarColor sets the color of the scroll bar shown when not all
+// rows fit, the visible part is shown in the text color.
+func (t *Table) SetScrollBarColor(color tcell.Color) *Table {
+	t.scrollBarColor = color
+	return t
+}
+
+// SetMarkColor sets the color of marked rows.
+func (t *Table) SetMarkColor(color tcell.Color) *Table {
+	t.markColor = color
+	return t
+}
+
 func (t *Table) SetBordersColor(color tcell.Color) *Table {
 	t.view.SetBordersColor(color)
 	return t
 }
 
+func (t *Table) SetTextColor(color tcell.Color) *Table {
+	t.textColor = color
+	return t
+}
+
+func (t *Table) SetSelectedStyle(foreground, background tcell.Color, attr tcell.AttrMask) *Table {
+	t.view.SetSelectedStyle(foreground, background, attr)
+	return t
+}
+
 
 func (t *Table) Render() {
 	t.view.Clear()
@@ -127,13 +277,27 @@ func (t *Table) Render() {
 		rows = t.filter.Filter(t.model.Contents())
 	}
 
+	columns := t.renderer.Columns()
 	for nRow, row := range rows { // for each row...
 		outputs := t.renderer.Render(row)
 		t.rowIndex[nRow] = row.Id()
-		for nCol := 0; nCol < row.Len(); nCol++ { // for each cell in the row...
-			// TODO: maybe move styling up
-			cell := tview.NewTableCell(outputs[nCol]).
-				SetTextColor(tcell.ColorWhite)
+		marked := t.marks[row.Id()]
+		for nCol, col := range columns { // for each cell in the row...
+			color := col.Color
+			if color == tcell.ColorDefault {
+				color = t.textColor
+			}
+			if marked {
+				color = t.markColor
+			}
+			text := tview.Escape(outputs[nCol])
+			if t.markable && nCol == 0 {
+				text = markers[marked] + text
+			}
+			cell := tview.NewTableCell(text).
+				SetTextColor(color).
+				SetAlign(col.Align).
+				SetExpansion(col.Expansion)
 
 			t.view.SetCell(nRow, nCol, cell)
 		}
@@ -141,21 +305,152 @@ func (t *Table) Render() {
 
 	//select first entry
 	t.view.Select(0,0)
+
+	if t.onRendered != nil {
+		t.onRendered(len(rows), len(t.model.Contents()))
+	}
 }
 
-func (t *Table) SelectionDown() {
-	row, col := t.view.GetSelection()
-	row += 1
-	if row < t.view.GetRowCount() {
-		t.view.Select(row, col)
+// Refresh renders the rows again, e.g. after their contents changed,
+// keeping the selected row selected.
+func (t *Table) Refresh() {
+	selected, found := t.GetSelectedRow()
+	t.Render()
+	if !found {
+		return
+	}
+	for r, id := range t.rowIndex {
+		if id == selected.Id() {
+			t.view.Select(r, 0)
+			return
+		}
 	}
 }
 
-func (t *Table) SelectionUp() {
+// markers are shown before marked rows, and in their place before the others
+var markers = map[bool]string{true: "● ", false: "  "}
+
+// SetMarkable sets whether rows can be marked, showing a marker before
+// each marked row.
+func (t *Table) SetMarkable(markable bool) *Table {
+	t.markable = markable
+	t.Refresh()
+	return t
+}
+
+// ToggleMark marks the selected row, or unmarks it if it is marked, and
+// moves the selection down.
+func (t *Table) ToggleMark() {
+	row, found := t.GetSelectedRow()
+	if !t.markable || !found {
+		return
+	}
+	if t.marks[row.Id()] {
+		delete(t.marks, row.Id())
+	} else {
+		t.marks[row.Id()] = true
+	}
+	t.Refresh()
+	t.moveSelection(1, false)
+}
+
+// ClearMarks unmarks all rows.
+func (t *Table) ClearMarks() {
+	t.marks = make(map[uint64]bool)
+	t.Refresh()
+}
+
+// MarkCount returns the number of rows marked.
+func (t *Table) MarkCount() int {
+	return len(t.marks)
+}
+
+// GetSelectedRows returns the marked rows, in the order of the model,
+// including rows the filter hides. If no row is marked, it returns the
+// selected row, if any.
+func (t *Table) GetSelectedRows() []Row {
+	if len(t.marks) == 0 {
+		if row, found := t.GetSelectedRow(); found {
+			return []Row{row}
+		}
+		return nil
+	}
+	rows := make([]Row, 0, len(t.marks))
+	for _, row := range t.model.Contents() {
+		if t.marks[row.Id()] {
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+// moveSelection moves the selection by delta rows. Past the first or last
+// row, the selection wraps around if wrap is set, and stops otherwise.
+func (t *Table) moveSelection(delta int, wrap bool) {
+	count := t.view.GetRowCount()
+	if count == 0 {
+		return
+	}
 	row, col := t.view.GetSelection()
-	if row > 0 {
-		t.view.Select(row-1, col)
+	row += delta
+	switch {
+	case wrap:
+		row = (row%count + count) % count
+	case row < 0:
+		row = 0
+	case row >= count:
+		row = count - 1
+	}
+	t.view.Select(row, col)
+}
+
+// selectAt selects the row shown at screen row y, reporting whether there is one.
+func (t *Table) selectAt(y int) bool {
+	_, top, _, _ := t.view.GetInnerRect()
+	offset, _ := t.view.GetOffset()
+	row := y - top + offset
+	if row < 0 || row >= t.view.GetRowCount() {
+		return false
 	}
+	t.view.Select(row, 0)
+	return true
+}
+
+// pageSize returns the number of rows shown at once.
+func (t *Table) pageSize() int {
+	_, _, _, height := t.view.GetInnerRect()
+	if height < 1 {
+		return 1
+	}
+	return height
+}
+
+func (t *Table) SelectionDown() {
+	t.moveSelection(1, t.wrapAround)
+}
+
+func (t *Table) SelectionUp() {
+	t.moveSelection(-1, t.wrapAround)
+}
+
+// SelectionPageDown moves the selection down by the number of rows shown.
+func (t *Table) SelectionPageDown() {
+	t.moveSelection(t.pageSize(), false)
+}
+
+// SelectionPageUp moves the selection up by the number of rows shown.
+func (t *Table) SelectionPageUp() {
+	t.moveSelection(-t.pageSize(), false)
+}
+
+// SelectionFirst selects the first row.
+func (t *Table) SelectionFirst() {
+	t.moveSelection(-t.view.GetRowCount(), false)
+}
+
+// SelectionLast selects the last row.
+func (t *Table) SelectionLast() {
+	t.moveSelection(t.view.GetRowCount(), false)
 }
 
 func (t *Table) GetSelection() (row int, col int) {
@@ -167,15 +462,23 @@ func (t *Table) GetSelection() (row int, col int) {
 
 func (t *Table) GetSelectedRow() (Row, bool) {
 	r, _ := t.view.GetSelection()
-	if r == -1 {
+	id, found := t.rowIndex[r]
+	if !found {
 		return nil, false
 	}
-	return t.model.LookUp(t.rowIndex[r])
+	return t.model.LookUp(id)
 }
 
 func (t *Table) Style(theme *ui.Base16Theme) {
 	t.SetBackgroundColor(theme.Background)
 	t.SetBordersColor(theme.Cyan)
+	t.SetTextColor(theme.Foreground)
+	t.SetScrollBarColor(theme.BrightBlack)
+	t.SetMarkColor(theme.BrightYellow)
+	t.SetSelectedStyle(theme.SelectedStyle())
+	if renderer, ok := t.renderer.(StyledRenderer); ok {
+		renderer.Style(theme)
+	}
 	t.Render()
 }
 
@@ -187,27 +490,28 @@ func NewTable(model *Model, renderer Renderer) *Table {
 	uiTable := tview.NewTable().SetBorders(false)
 	// selection spans whole row
 	uiTable.SetSelectable(true, false)
+	// keep the columns aligned while scrolling
+	uiTable.SetEvaluateAllRows(true)
 
 	t := &Table{
 		model: model,
-		view: uiTable,
 		renderer: renderer,
+		textColor: tcell.ColorWhite,
+		backgroundColor: tview.Styles.PrimitiveBackgroundColor,
+		scrollBarColor: tcell.ColorGray,
+		markColor: tcell.ColorYellow,
+		marks: make(map[uint64]bool),
 	}
+	t.view = &view{Table: uiTable, table: t}
 
-	uiTable.SetDoneFunc(func(key tcell.Key) {
-		if key == tcell.KeyTAB {
-			if t.onTab != nil {
-				t.onTab()
-			}
-		} else if key == tcell.KeyEscape {
-			if t.onEsc != nil {
-				t.onEsc()
+	model.SetOnChanged(func() {
+		// forget the marks of rows no longer in the model
+		for id := range t.marks {
+			if _, found := model.LookUp(id); !found {
+				delete(t.marks, id)
 			}
 		}
-	})
-
-	model.SetOnChanged(func() {
-		t.Render()
+		t.Refresh()
 	})
 	t.Render()
 
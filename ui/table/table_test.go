@@ -0,0 +1,123 @@
+package table
+
+import (
+	"fmt"
+	"testing"
+)
+
+type testRow int
+
+func (r testRow) Id() uint64 { return uint64(r) }
+
+type testRenderer struct{}
+
+func (testRenderer) Columns() []Column {
+	return []Column{NewColumn("n")}
+}
+
+func (testRenderer) Render(row Row) []string {
+	return []string{fmt.Sprint(int(row.(testRow)))}
+}
+
+func TestTableSelection(t *testing.T) {
+	rows := make([]Row, 10)
+	for i := range rows {
+		rows[i] = testRow(i)
+	}
+	table := NewTable(NewTableModel(rows), testRenderer{})
+	table.view.SetRect(0, 0, 20, 4)
+
+	expectRow := func(step string, expected int) {
+		t.Helper()
+		if row, _ := table.GetSelection(); row != expected {
+			t.Errorf("%s: expected row %d, got %d", step, expected, row)
+		}
+	}
+	table.SelectionUp()
+	expectRow("up from the first row", 0)
+	table.SelectionPageDown()
+	expectRow("page down", 4)
+	table.SelectionLast()
+	expectRow("last", 9)
+	table.SelectionPageDown()
+	expectRow("page down from the last row", 9)
+	table.SelectionDown()
+	expectRow("down from the last row", 9)
+
+	table.SetWrapAround(true)
+	table.SelectionDown()
+	expectRow("down from the last row, wrapping around", 0)
+	table.SelectionUp()
+	expectRow("up from the first row, wrapping around", 9)
+	table.SelectionFirst()
+	expectRow("first", 0)
+}
+
+func TestTableMarks(t *testing.T) {
+	rows := make([]Row, 5)
+	for i := range rows {
+		rows[i] = testRow(i)
+	}
+	table := NewTable(NewTableModel(rows), testRenderer{})
+	table.view.SetRect(0, 0, 20, 5)
+
+	table.ToggleMark()
+	if table.MarkCount() != 0 {
+		t.Error("expected rows not to be marked unless markable")
+	}
+	if selected := table.GetSelectedRows(); len(selected) != 1 || selected[0] != testRow(0) {
+		t.Errorf("expected the selected row without marks, got %v", selected)
+	}
+
+	table.SetMarkable(true)
+	table.SelectionLast()
+	table.ToggleMark()
+	table.SelectionFirst()
+	table.ToggleMark()
+	table.ToggleMark()
+	if cell := table.view.GetCell(0, 0); cell.Text != "● 0" {
+		t.Errorf("expected a marker before the marked row, got '%s'", cell.Text)
+	}
+	if cell := table.view.GetCell(2, 0); cell.Text != "  2" {
+		t.Errorf("expected room for the marker before unmarked rows, got '%s'", cell.Text)
+	}
+	// in the order of the model, not of marking
+	expected := []Row{testRow(0), testRow(1), testRow(4)}
+	if selected := table.GetSelectedRows(); fmt.Sprint(selected) != fmt.Sprint(expected) {
+		t.Errorf("expected %v, got %v", expected, selected)
+	}
+	table.SelectionFirst()
+	table.ToggleMark()
+	if table.MarkCount() != 2 {
+		t.Errorf("expected toggling a marked row to unmark it, %d marked", table.MarkCount())
+	}
+	table.ClearMarks()
+	if table.MarkCount() != 0 {
+		t.Errorf("expected no marks left, got %d", table.MarkCount())
+	}
+}
+
+func TestTableModelChanged(t *testing.T) {
+	rows := make([]Row, 5)
+	for i := range rows {
+		rows[i] = testRow(i)
+	}
+	model := NewTableModel(rows)
+	table := NewTable(model, testRenderer{})
+	table.view.SetRect(0, 0, 20, 5)
+	table.SetMarkable(true)
+	table.ToggleMark()
+	table.SelectionLast()
+	table.ToggleMark()
+	table.SelectionUp()
+	table.SelectionUp()
+
+	// the first row is removed, the last one is kept
+	model.SetContents([]Row{testRow(1), testRow(2), testRow(3), testRow(4)})
+	if row, found := table.GetSelectedRow(); !found || row != testRow(2) {
+		t.Errorf("expected the selected row to stay selected, got %v", row)
+	}
+	if table.MarkCount() != 1 {
+		t.Errorf("expected the mark of the removed row to be forgotten, %d marked", table.MarkCount())
+	}
+}
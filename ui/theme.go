@@ -0,0 +1,73 @@
+package ui
+
+import (
+	"github.com/gdamore/tcell"
+	"github.com/rivo/tview"
+)
+
+// Base16Theme assigns colors to the 16 roles of a terminal palette.
+// Themes are made from base16 schemes, see Scheme.
+type Base16Theme struct {
+	Name string
+	// Monochrome is set if the terminal cannot show colors, in which case
+	// all colors are tcell.ColorDefault and widgets should use text
+	// attributes (underline, reverse video) to stand out instead.
+	Monochrome bool
+
+	Background tcell.Color
+	Foreground tcell.Color
+
+	Black   tcell.Color
+	Red     tcell.Color
+	Green   tcell.Color
+	Yellow  tcell.Color
+	Blue    tcell.Color
+	Magenta tcell.Color
+	Cyan    tcell.Color
+	White   tcell.Color
+
+	BrightBlack   tcell.Color
+	BrightRed     tcell.Color
+	BrightGreen   tcell.Color
+	BrightYellow  tcell.Color
+	BrightBlue    tcell.Color
+	BrightMagenta tcell.Color
+	BrightCyan    tcell.Color
+	BrightWhite   tcell.Color
+}
+
+// SelectedStyle returns the style of selected rows: the foreground and
+// background colors swapped, or reverse video if the terminal's default
+// colors are used.
+func (t *Base16Theme) SelectedStyle() (fg, bg tcell.Color, attr tcell.AttrMask) {
+	if t.Background == tcell.ColorDefault || t.Foreground == tcell.ColorDefault {
+		return t.Foreground, t.Background, tcell.AttrReverse
+	}
+	return t.Background, t.Foreground, 0
+}
+
+// StyleDefaults sets tview's default colors, used by widgets which are not
+// styled explicitly.
+func (t *Base16Theme) StyleDefaults() {
+	tview.Styles.PrimitiveBackgroundColor = t.Background
+	tview.Styles.ContrastBackgroundColor = t.BrightBlack
+	tview.Styles.MoreContrastBackgroundColor = t.BrightBlack
+	tview.Styles.BorderColor = t.Cyan
+	tview.Styles.TitleColor = t.Foreground
+	tview.Styles.GraphicsColor = t.Cyan
+	tview.Styles.PrimaryTextColor = t.Foreground
+	tview.Styles.SecondaryTextColor = t.Yellow
+	tview.Styles.TertiaryTextColor = t.Green
+	tview.Styles.InverseTextColor = t.Blue
+	tview.Styles.ContrastSecondaryTextColor = t.BrightCyan
+}
+
+func (t *Base16Theme) StyleTextView(text *tview.TextView) {
+	text.SetBackgroundColor(t.Background)
+	text.SetTextColor(t.Foreground)
+}
+
+func (t *Base16Theme) StyleGrid(grid *tview.Grid) {
+	grid.SetBackgroundColor(t.Background)
+	grid.SetBordersColor(t.Cyan)
+}
@@ -0,0 +1,29 @@
+package utils
+
+import "fmt"
+
+// Batch is several commands, picked together to be run one after another.
+type Batch struct {
+	Commands []*Command
+}
+
+// Command returns a multi-step command of the steps of all commands, to fill
+// in their variables at once: a variable used by several commands is asked
+// for once. A step failing does not stop the commands after it.
+func (b *Batch) Command() *Command {
+	batch := &Command{
+		Desc:    fmt.Sprintf("%d commands", len(b.Commands)),
+		OnError: OnErrorContinue,
+		Vars:    make(map[string]string),
+	}
+	for _, command := range b.Commands {
+		batch.Steps = append(batch.Steps, command.Templates()...)
+		batch.Confirm = batch.Confirm || command.Confirm
+		for name, desc := range command.Vars {
+			if _, found := batch.Vars[name]; !found {
+				batch.Vars[name] = desc
+			}
+		}
+	}
+	return batch
+}
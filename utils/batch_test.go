@@ -0,0 +1,28 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBatchCommand(t *testing.T) {
+	batch := &Batch{Commands: []*Command{
+		{Cmd: "make clean", Desc: "clean"},
+		{Steps: []string{"docker compose -p %(project) down", "docker volume prune -f"}, Vars: map[string]string{"project": "compose project"}},
+		{Cmd: "rm -rf build/%(project)", Confirm: true, Vars: map[string]string{"project": "other description"}},
+	}}
+	command := batch.Command()
+	expectedSteps := []string{"make clean", "docker compose -p %(project) down", "docker volume prune -f", "rm -rf build/%(project)"}
+	if !reflect.DeepEqual(command.Steps, expectedSteps) {
+		t.Errorf("expected steps %q, got %q", expectedSteps, command.Steps)
+	}
+	if names, _ := command.VarNames(); !reflect.DeepEqual(names, []string{"project"}) {
+		t.Errorf("expected project to be asked for once, got %v", names)
+	}
+	if command.Vars["project"] != "compose project" {
+		t.Errorf("expected the first description of project, got '%s'", command.Vars["project"])
+	}
+	if !command.Confirm {
+		t.Error("expected the batch to be confirmed, as one of its commands must be")
+	}
+}
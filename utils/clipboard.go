@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// clipboardTools are programs copying their input to the clipboard, tried in
+// order if the display server they need is running
+var clipboardTools = []struct {
+	display string
+	name    string
+	args    []string
+}{
+	{"WAYLAND_DISPLAY", "wl-copy", nil},
+	{"DISPLAY", "xclip", []string{"-selection", "clipboard"}},
+}
+
+// osc52 returns the terminal escape setting the clipboard to text.
+func osc52(text string) string {
+	return "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte(text)) + "\a"
+}
+
+// CopyToClipboard copies text to the clipboard, using the OSC 52 terminal
+// escape and, if present, wl-copy or xclip. Whether the terminal supports
+// OSC 52 is not known, so both are used. It returns how text was copied.
+func CopyToClipboard(text string) ([]string, error) {
+	var tty io.Writer = os.Stdout
+	if f, err := os.OpenFile("/dev/tty", os.O_WRONLY, 0); err == nil {
+		defer f.Close()
+		tty = f
+	}
+	if _, err := io.WriteString(tty, osc52(text)); err != nil {
+		return nil, fmt.Errorf("copying to the clipboard: %v", err)
+	}
+	methods := []string{"OSC 52"}
+	for _, tool := range clipboardTools {
+		if os.Getenv(tool.display) == "" {
+			continue
+		}
+		if _, err := exec.LookPath(tool.name); err != nil {
+			continue
+		}
+		cmd := exec.Command(tool.name, tool.args...)
+		cmd.Stdin = strings.NewReader(text)
+		if err := cmd.Run(); err != nil {
+			return methods, fmt.Errorf("%s: %v", tool.name, err)
+		}
+		methods = append(methods, tool.name)
+		break
+	}
+	return methods, nil
+}
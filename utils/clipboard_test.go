@@ -0,0 +1,10 @@
+package utils
+
+import "testing"
+
+func TestOsc52(t *testing.T) {
+	expected := "\x1b]52;c;ZWNobyBow6lsbG8=\a"
+	if seq := osc52("echo héllo"); seq != expected {
+		t.Errorf("expected %q, got %q", expected, seq)
+	}
+}
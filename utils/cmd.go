@@ -1,39 +1,126 @@
 package utils
 
 import (
+	"errors"
 	"fmt"
 	"github.com/google/shlex"
 	"os"
 	"os/exec"
+	"os/signal"
 	"strings"
+	"syscall"
 )
 
-func Run(cmd string) error {
+// ErrEmptyCommand is returned for a command which is blank, e.g. once its
+// only variable is filled in with nothing.
+var ErrEmptyCommand = errors.New("the command is empty")
+
+// splitCommand splits cmd into the program and its arguments.
+func splitCommand(cmd string) ([]string, error) {
 	lexemes, err := shlex.Split(cmd)
+	if err != nil {
+		return nil, err
+	}
+	if len(lexemes) == 0 {
+		return nil, ErrEmptyCommand
+	}
+	return lexemes, nil
+}
+
+// capturedEnv is the environment of commands whose output is captured. No
+// keys are typed into their terminal, so pagers such as less, started by
+// e.g. git log or man, would wait forever.
+func capturedEnv() []string {
+	return append(os.Environ(), "PAGER=cat", "GIT_PAGER=cat", "MANPAGER=cat", "SYSTEMD_PAGER=")
+}
+
+// Run runs cmd in directory dir, an empty dir runs it in the current directory.
+//
+// While the command runs, SIGTERM and SIGHUP are forwarded to it. SIGINT and
+// SIGQUIT are caught and dropped by spellbook, from before the command starts,
+// as the terminal already delivers these to the command which shares our
+// process group. Ignoring them instead would be inherited by the command.
+func Run(cmd string, dir string) error {
+	lexemes, err := splitCommand(cmd)
 	if err != nil {
 		return err
 	}
 
+	intr := make(chan os.Signal, 1)
+	signal.Notify(intr, syscall.SIGINT, syscall.SIGQUIT)
+	defer signal.Stop(intr)
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGTERM, syscall.SIGHUP)
+	defer signal.Stop(sigs)
+
 	c := exec.Command(lexemes[0], lexemes[1:]...)
+	c.Dir = dir
 	c.Stdin = os.Stdin
 	c.Stdout = os.Stdout
 	c.Stderr = os.Stderr
-	return c.Run()
+	if err := c.Start(); err != nil {
+		return err
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- c.Wait()
+	}()
+	for {
+		select {
+		case sig := <-sigs:
+			_ = c.Process.Signal(sig)
+		case err := <-done:
+			return err
+		}
+	}
+}
+
+// Exec replaces the spellbook process with cmd, run in directory dir.
+// Exec only returns if the command could not be started.
+func Exec(cmd string, dir string) error {
+	lexemes, err := splitCommand(cmd)
+	if err != nil {
+		return err
+	}
+	if dir != "" {
+		if err := os.Chdir(dir); err != nil {
+			return err
+		}
+	}
+	path, err := exec.LookPath(lexemes[0])
+	if err != nil {
+		return err
+	}
+	return syscall.Exec(path, lexemes, os.Environ())
 }
 
-// ExitCode returns the program exit code (if any)
+// ExitCode returns the exit code a shell would report for the command
 // -1: (probably) ignore - most commands set error-codes between 0-255
 // 0: the command exited successfully
 // 0-255: program exit code
+// 126: the command could not be executed
+// 127: the command was not found
+// 128+N: the command was killed by signal N
 func ExitCode(err error) int {
 	if err == nil {
 		return 0
 	}
 
 	if e, ok := err.(*exec.ExitError); ok {
+		if status, ok := e.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+			return 128 + int(status.Signal())
+		}
 		return e.ExitCode()
 	}
 
+	if errors.Is(err, exec.ErrNotFound) || errors.Is(err, os.ErrNotExist) {
+		return 127
+	} else if errors.Is(err, os.ErrPermission) {
+		return 126
+	}
+
 	// Negative exit codes are technically allowed by POSIX
 	// but e.g. the wait-family of system calls truncates value to
 	// be an unsigned 1B (0-255) val are supported.
@@ -64,6 +151,29 @@ func ResolveEnvVars(s string) (string, error) {
 	return resolved, nil
 }
 
+// EnvSubstitution is an environment variable referenced by a command
+type EnvSubstitution struct {
+	Name  string
+	Value string
+	Found bool
+}
+
+// EnvSubstitutions lists the environment variables referenced in s, in order
+// of first appearance, along with the values ResolveEnvVars would insert.
+func EnvSubstitutions(s string) []EnvSubstitution {
+	subs := make([]EnvSubstitution, 0)
+	seen := make(map[string]bool)
+	os.Expand(s, func(key string) string {
+		if !seen[key] {
+			seen[key] = true
+			val, found := os.LookupEnv(key)
+			subs = append(subs, EnvSubstitution{Name: key, Value: val, Found: found})
+		}
+		return ""
+	})
+	return subs
+}
+
 type TokType uint8
 const (
 	TokLiteral = iota
@@ -81,7 +191,7 @@ type InvalidVarNameError struct {
 }
 
 func (ie *InvalidVarNameError) Error() string {
-	return fmt.Sprintf("Invalid variable name '%s' in command '%s'. Only a-zA-Z_- allowed", ie.VarName, ie.Command)
+	return fmt.Sprintf("Invalid variable name '%s' in command '%s'. Only a-zA-Z_- and digits, except as the first character, allowed", ie.VarName, ie.Command)
 }
 
 func NewInvalidVarNameError(cmd string, varName string) *InvalidVarNameError {
@@ -148,8 +258,10 @@ func ParseCmd(cmd string) ([]Token, error) {
 
 		// variable identifier
 		ident := cmd[start:pos]
-		for _, ch := range ident {
+		for i, ch := range ident {
 			if (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') || ch == '-' || ch == '_' {
+			} else if ch >= '0' && ch <= '9' && i != 0 {
+				// digits may follow the first character, e.g. %(file2)
 			} else {
 				return nil, NewInvalidVarNameError(cmd, ident)
 			}
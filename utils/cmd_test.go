@@ -1,6 +1,15 @@
 package utils
 
-import "testing"
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os/exec"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
 
 
 type EnvParseTestCase struct {
@@ -191,6 +200,22 @@ func TestEnvParse_Var4_LeadingVar(t *testing.T) {
 	})
 }
 
+func TestEnvParse_Var5_Digits(t *testing.T) {
+	testEnvParse(t, &EnvParseTestCase{
+		desc: "digits may follow the first character of a variable",
+		input: `cp %(file1) %(file2)`,
+		toks: []Token{
+			{TokLiteral, `cp `},
+			{TokVar, `file1`},
+			{TokLiteral, ` `},
+			{TokVar, `file2`},
+		},
+	})
+	if _, err := ParseCmd(`echo %(1st)`); err == nil {
+		t.Error("Expected error for a variable name starting with a digit")
+	}
+}
+
 func TestEnvParse_EscapedVar(t *testing.T) {
 	testEnvParse(t, &EnvParseTestCase{
 		desc: "'%%(other)' will be escaped as literal",
@@ -223,3 +248,70 @@ func TestEnvParse_BadVarname(t *testing.T) {
 	}
 }
 
+func TestExitCode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires a POSIX shell")
+	}
+	cases := []struct {
+		desc     string
+		cmd      string
+		expected int
+	}{
+		{"success", `true`, 0},
+		{"failure", `sh -c "exit 3"`, 3},
+		{"killed by SIGTERM", `sh -c "kill -TERM $$"`, 128 + 15},
+		{"killed by SIGKILL", `sh -c "kill -KILL $$"`, 128 + 9},
+		{"not found", `spellbook-no-such-command`, 127},
+	}
+	for _, c := range cases {
+		if code := ExitCode(Run(c.cmd, "")); code != c.expected {
+			t.Errorf("%s: expected exit code %d, got %d", c.desc, c.expected, code)
+		}
+	}
+
+	if code := ExitCode(exec.Command("./cmd_test.go").Run()); code != 126 {
+		t.Errorf("not executable: expected exit code 126, got %d", code)
+	}
+}
+
+func TestRunEmptyCommand(t *testing.T) {
+	for _, cmd := range []string{"", "   "} {
+		if err := Run(cmd, ""); err != ErrEmptyCommand {
+			t.Errorf("'%s': expected ErrEmptyCommand from Run, got %v", cmd, err)
+		}
+		err := RunCaptured(context.Background(), cmd, "", ioutil.Discard, 80, 24)
+		if err != ErrEmptyCommand {
+			t.Errorf("'%s': expected ErrEmptyCommand from RunCaptured, got %v", cmd, err)
+		}
+	}
+}
+
+func TestRunCapturedNoPager(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires a POSIX shell")
+	}
+	// paged by less, as git log does, nothing would ever quit the pager
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var out bytes.Buffer
+	err := RunCaptured(ctx, `sh -c "seq 1 200 | ${PAGER:-less}"`, "", &out, 80, 24)
+	if ctx.Err() != nil {
+		t.Fatal("expected the command not to wait in a pager")
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "200") {
+		t.Errorf("expected the whole output, got '%s'", out.String())
+	}
+}
+
+func TestRunStepsEmptyStep(t *testing.T) {
+	// e.g. a step which is only a variable, filled in with nothing
+	results := RunSteps([]string{"", "true"}, false, func(i int, cmd string) error {
+		return Run(cmd, "")
+	})
+	if results[0].Err != ErrEmptyCommand || !results[1].Skipped {
+		t.Errorf("expected the empty step to fail and the next to be skipped, got %+v", results)
+	}
+}
@@ -3,12 +3,40 @@ package utils
 import (
 	"errors"
 	"fmt"
+	"github.com/mitchellh/go-homedir"
 	"github.com/spf13/viper"
 	"gopkg.in/yaml.v2"
+	"os"
+	"path/filepath"
+	"strings"
 )
 
 const configName = ".spellbook"
 
+// Modes of running a command
+const (
+	// ModeInline runs a command inside the UI, showing its output in a pane
+	ModeInline = "inline"
+	// ModeChecklist shows the steps of a command as a checklist, each step is run individually
+	ModeChecklist = "checklist"
+)
+
+// On-error policies of multi-step commands
+const (
+	// OnErrorStop skips the remaining steps once a step fails (default)
+	OnErrorStop = "stop"
+	// OnErrorContinue runs every step, regardless of earlier failures
+	OnErrorContinue = "continue"
+)
+
+// CwdConfigDir is the special `cwd:` value which resolves to the directory
+// containing the config file defining the command.
+const CwdConfigDir = "@config"
+
+// CwdWorkingDir is the special `cwd:` value which runs the command in
+// spellbook's working directory.
+const CwdWorkingDir = "@cwd"
+
 func PrettyPrintConfig() (string, error) {
 	bs, err := yaml.Marshal(viper.AllSettings())
 	if err != nil {
@@ -20,14 +48,200 @@ func PrettyPrintConfig() (string, error) {
 type Command struct {
 	Cmd string
 	Desc string
+	// Cwd is the directory to run the command in. Relative paths are
+	// resolved against the directory of the defining config file.
+	// If empty, this is the directory of the defining config file, except
+	// for the global config, whose commands run in spellbook's working
+	// directory as do those with CwdWorkingDir.
+	Cwd string
+	// Confirm requires the user to confirm the command before it is run
+	Confirm bool
+	// Mode is how the command is run, "" (leave the UI), ModeInline or ModeChecklist
+	Mode string
+	// Steps are run in order instead of Cmd. The steps share their variables.
+	Steps []string
+	// OnError is what to do when a step fails, OnErrorStop or OnErrorContinue
+	OnError string `mapstructure:"on-error"`
+	// Vars describes the command's variables, by name
+	Vars map[string]string
+	// Tags group related commands
+	Tags []string
+
+	// Source is the config file which defined the command
+	Source string `mapstructure:"-"`
+	// SourceName is the name of the CommandSource which provided the command
+	SourceName string `mapstructure:"-"`
+}
+
+// validate returns an error if the command cannot be run as written, e.g.
+// if its mode is misspelled.
+func (c *Command) validate() error {
+	if len(c.Steps) == 0 && strings.TrimSpace(c.Cmd) == "" {
+		return errors.New("cmd is empty")
+	}
+	switch c.Mode {
+	case "", ModeInline, ModeChecklist:
+	default:
+		return fmt.Errorf("unknown mode '%s', expected %s or %s", c.Mode, ModeInline, ModeChecklist)
+	}
+	for i, step := range c.Steps {
+		if strings.TrimSpace(step) == "" {
+			return fmt.Errorf("step %d is empty", i+1)
+		}
+	}
+	switch c.OnError {
+	case "", OnErrorStop, OnErrorContinue:
+	default:
+		return fmt.Errorf("unknown on-error '%s', expected %s or %s", c.OnError, OnErrorStop, OnErrorContinue)
+	}
+	return nil
+}
+
+// WorkDir returns the directory the command should be run in.
+// An empty string means the current working directory.
+func (c *Command) WorkDir() (string, error) {
+	if c.Cwd == CwdWorkingDir || c.Source == "" {
+		return "", nil
+	}
+	base := filepath.Dir(c.Source)
+	if c.Cwd == "" {
+		// commands of the global config are run from anywhere
+		if home, err := homedir.Dir(); err == nil && base == home {
+			return "", nil
+		}
+		return base, nil
+	}
+	if c.Cwd == CwdConfigDir {
+		return base, nil
+	}
+	dir, err := homedir.Expand(c.Cwd)
+	if err != nil {
+		return "", err
+	}
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(base, dir)
+	}
+	return dir, nil
+}
+
+// Text returns the command as it would be written in a shell.
+func (c *Command) Text() string {
+	if len(c.Steps) == 0 {
+		return c.Cmd
+	}
+	if c.OnError == OnErrorContinue {
+		return strings.Join(c.Steps, "; ")
+	}
+	return strings.Join(c.Steps, " && ")
+}
+
+// Template returns the template completed by the user before the command is run.
+// For multi-step commands this asks for each variable once, and is a copy of
+// the steps if the steps have no variables.
+func (c *Command) Template() (string, error) {
+	if len(c.Steps) == 0 {
+		return c.Cmd, nil
+	}
+	names, err := c.VarNames()
+	if err != nil {
+		return "", err
+	}
+	if len(names) == 0 {
+		return EscapeTemplate(c.Text()), nil
+	}
+	return PromptTemplate(names), nil
+}
+
+// VarNames returns the names of the variables across all steps, in order of first appearance.
+func (c *Command) VarNames() ([]string, error) {
+	toks := make([]Token, 0)
+	for _, step := range c.Templates() {
+		stepToks, err := ParseCmd(step)
+		if err != nil {
+			return nil, err
+		}
+		toks = append(toks, stepToks...)
+	}
+	return VarNames(toks), nil
+}
+
+// ExpandSteps returns the steps of the command with the variables replaced by values.
+func (c *Command) ExpandSteps(values map[string]string) ([]string, error) {
+	steps := c.Templates()
+	out := make([]string, len(steps))
+	for i, step := range steps {
+		toks, err := ParseCmd(step)
+		if err != nil {
+			return nil, err
+		}
+		if out[i], err = Expand(toks, values); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// Templates returns the command templates, one per step for multi-step commands.
+func (c *Command) Templates() []string {
+	if len(c.Steps) == 0 {
+		return []string{c.Cmd}
+	}
+	return c.Steps
 }
 
 type Config struct {
+	// Cwd is the default `cwd:` of every command in the file
+	Cwd string
+	// Theme is the name of a built-in theme or the path of a base16 scheme file
+	Theme string
+	// Columns are the columns of the command table, e.g. cmd, desc, tags
+	Columns []string
+	// Keymap is the name of a preset of key bindings, e.g. emacs or vi
+	Keymap string
+	// Keys are the keys bound to actions, overriding the keymap
+	Keys map[string][]string
+	// WrapAround moves the selection from the last command to the first, and back
+	WrapAround *bool `mapstructure:"wrap-around"`
+	// Sources enables or disables kinds of command sources by name, e.g. make: false
+	Sources map[string]bool
 	Commands []Command
+
+	// Files are the config files read, in order
+	Files []string `mapstructure:"-"`
+	// ThemeDir is the directory of the config file setting Theme, which
+	// the path of a scheme file is relative to
+	ThemeDir string `mapstructure:"-"`
 }
 
 func (c *Config) merge(other *Config) error {
 	c.Commands = append(c.Commands, other.Commands...)
+	c.Files = append(c.Files, other.Files...)
+	// later configs (e.g. the project's) override earlier ones
+	if other.Theme != "" {
+		c.Theme = other.Theme
+		c.ThemeDir = other.ThemeDir
+	}
+	if len(other.Columns) != 0 {
+		c.Columns = other.Columns
+	}
+	if other.Keymap != "" {
+		c.Keymap = other.Keymap
+	}
+	if other.WrapAround != nil {
+		c.WrapAround = other.WrapAround
+	}
+	for kind, enabled := range other.Sources {
+		if c.Sources == nil {
+			c.Sources = make(map[string]bool)
+		}
+		c.Sources[kind] = enabled
+	}
+	for action, keys := range other.Keys {
+		if c.Keys == nil {
+			c.Keys = make(map[string][]string)
+		}
+		c.Keys[action] = keys
+	}
 	return nil
 }
 
@@ -77,20 +291,60 @@ func unmarshalRawConfigs(rawConfigs []*viper.Viper) ([]*Config, error) {
 				"cannot unmarshal",
 				err}
 		}
+		source, err := filepath.Abs(rawConfig.ConfigFileUsed())
+		if err != nil {
+			return nil, &ConfigError{
+				rawConfig.ConfigFileUsed(),
+				"cannot resolve path",
+				err}
+		}
+		conf.Files = []string{source}
+		conf.ThemeDir = filepath.Dir(source)
+		for i := range conf.Commands {
+			conf.Commands[i].Source = source
+			if conf.Commands[i].Cwd == "" {
+				conf.Commands[i].Cwd = conf.Cwd
+			}
+		}
 		res = append(res, &conf)
 	}
 	return res, nil
 }
 
+// FindProjectDir returns the closest directory, starting from dir and walking
+// up towards the root, which holds a spellbook config file.
+func FindProjectDir(dir string) (string, bool) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", false
+	}
+	for {
+		for _, ext := range viper.SupportedExts {
+			info, err := os.Stat(filepath.Join(dir, configName+"."+ext))
+			if err == nil && !info.IsDir() {
+				return dir, true
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
+
 type Mergeable interface {
 	merge(val Mergeable) interface{}
 }
 
+// ErrNoConfig is returned by ReadConfig if none of the directories holds a config file
+var ErrNoConfig = errors.New("no configs to read")
+
 func ReadConfig(configDirs []string) (*Config, error) {
 	// read in all available configs
 	rawConfigs := readRawConfigs(configDirs)
 	if len(rawConfigs) == 0 {
-		return nil, errors.New("no configs to read")
+		return nil, ErrNoConfig
 	}
 
 	// unmarshal each config into its own Config instance
@@ -0,0 +1,149 @@
+package utils
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mitchellh/go-homedir"
+)
+
+func TestCommand_WorkDir(t *testing.T) {
+	source := filepath.Join("/projects", "foo", ".spellbook.yml")
+	cases := []struct {
+		cwd      string
+		expected string
+	}{
+		{"", "/projects/foo"},
+		{CwdWorkingDir, ""},
+		{CwdConfigDir, "/projects/foo"},
+		{".", "/projects/foo"},
+		{"scripts", "/projects/foo/scripts"},
+		{"../bar", "/projects/bar"},
+		{"/tmp", "/tmp"},
+	}
+	for _, c := range cases {
+		cmd := Command{Cmd: "make test", Cwd: c.cwd, Source: source}
+		dir, err := cmd.WorkDir()
+		if err != nil {
+			t.Errorf("cwd '%s': unexpected error: %v", c.cwd, err)
+		}
+		if dir != filepath.FromSlash(c.expected) {
+			t.Errorf("cwd '%s': expected '%s', got '%s'", c.cwd, c.expected, dir)
+		}
+	}
+}
+
+func TestCommand_WorkDirGlobal(t *testing.T) {
+	home, err := homedir.Dir()
+	if err != nil {
+		t.Skip(err)
+	}
+	cmd := Command{Cmd: "git status", Source: filepath.Join(home, ".spellbook.yml")}
+	if dir, err := cmd.WorkDir(); err != nil || dir != "" {
+		t.Errorf("expected commands of the global config to run in the working directory, got '%s' (%v)", dir, err)
+	}
+}
+
+func TestFindProjectDir(t *testing.T) {
+	root, err := ioutil.TempDir("", "spellbook")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	sub := filepath.Join(root, "a", "b")
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(root, ".spellbook.yml"), []byte("commands: []\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	dir, found := FindProjectDir(sub)
+	if !found {
+		t.Fatalf("expected to find project dir from '%s'", sub)
+	}
+	if dir != root {
+		t.Errorf("expected '%s', got '%s'", root, dir)
+	}
+}
+
+func TestReadConfig(t *testing.T) {
+	global, err := ioutil.TempDir("", "spellbook")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(global)
+	project, err := ioutil.TempDir("", "spellbook")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(project)
+
+	globalConf := "theme: monokai\nkeymap: vi\nkeys:\n  toggle-preview: ctrl-o\n  cancel: [esc, ctrl-c]\ncommands:\n  - cmd: echo %(name)\n    vars:\n      name: who to greet\n"
+	if err := ioutil.WriteFile(filepath.Join(global, ".spellbook.yml"), []byte(globalConf), 0644); err != nil {
+		t.Fatal(err)
+	}
+	projectConf := "theme: gruvbox-dark\nkeys:\n  cancel: ctrl-g\ncommands:\n  - cmd: make\n"
+	if err := ioutil.WriteFile(filepath.Join(project, ".spellbook.yml"), []byte(projectConf), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	conf, err := ReadConfig([]string{global, project})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(conf.Commands) != 2 {
+		t.Fatalf("expected 2 commands, got %d", len(conf.Commands))
+	}
+	if conf.Theme != "gruvbox-dark" {
+		t.Errorf("expected the project theme to win, got '%s'", conf.Theme)
+	}
+	if conf.ThemeDir != project {
+		t.Errorf("expected the theme to be relative to the project, got '%s'", conf.ThemeDir)
+	}
+	if conf.Keymap != "vi" {
+		t.Errorf("expected the global keymap, got '%s'", conf.Keymap)
+	}
+	expectedKeys := map[string][]string{"toggle-preview": {"ctrl-o"}, "cancel": {"ctrl-g"}}
+	if !reflect.DeepEqual(conf.Keys, expectedKeys) {
+		t.Errorf("expected keys %v, got %v", expectedKeys, conf.Keys)
+	}
+	if len(conf.Files) != 2 || filepath.Dir(conf.Files[1]) != project {
+		t.Errorf("unexpected files %v", conf.Files)
+	}
+	if desc := conf.Commands[0].Vars["name"]; desc != "who to greet" {
+		t.Errorf("expected var description, got '%s'", desc)
+	}
+}
+
+func TestInvalidCommands(t *testing.T) {
+	cases := map[string]string{
+		"commands:\n  - cmd: make\n    mode: inlnie\n":       "unknown mode 'inlnie'",
+		"commands:\n  - cmd: '  '\n":                         "cmd is empty",
+		"commands:\n  - steps: [make, '']\n":                 "step 2 is empty",
+		"commands:\n  - steps: [make]\n    on-error: skip\n": "unknown on-error 'skip'",
+	}
+	for conf, expected := range cases {
+		dir, err := ioutil.TempDir("", "spellbook")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+		if err := ioutil.WriteFile(filepath.Join(dir, ".spellbook.yml"), []byte(conf), 0644); err != nil {
+			t.Fatal(err)
+		}
+		// the config is read, the commands of the file fail to load
+		conf, err := ReadConfig([]string{dir})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Sources(conf)[0].Load(); err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("expected an error containing '%s', got %v", expected, err)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package utils
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/google/shlex"
+)
+
+// IsDangerous reports whether cmd looks like it could destroy data.
+// This is a heuristic, commands which should always be confirmed
+// should set `confirm: true` instead.
+func IsDangerous(cmd string) bool {
+	lexemes, err := shlex.Split(cmd)
+	if err != nil {
+		lexemes = strings.Fields(cmd)
+	}
+
+	isGitPush := false
+	for i, lexeme := range lexemes {
+		if isCommandPosition(lexemes, i) {
+			isGitPush = false
+			switch name := filepath.Base(lexeme); {
+			case name == "dd", name == "mkfs", strings.HasPrefix(name, "mkfs."):
+				return true
+			case name == "rm":
+				if isRecursiveForce(lexemes[i+1:]) {
+					return true
+				}
+			case name == "git":
+				sub := gitSubcommand(lexemes[i+1:])
+				isGitPush = sub != -1 && lexemes[i+1+sub] == "push"
+			}
+		}
+
+		if lexeme == "--delete" {
+			return true
+		}
+		if isGitPush && (lexeme == "-f" || lexeme == "--force" ||
+			strings.HasPrefix(lexeme, "--force-with-lease") ||
+			(strings.HasPrefix(lexeme, "+") && len(lexeme) > 1)) {
+			return true
+		}
+	}
+	return false
+}
+
+// isCommandPosition reports whether lexemes[i] is (likely) the name of the
+// program being run, rather than an argument to another program.
+func isCommandPosition(lexemes []string, i int) bool {
+	if i == 0 {
+		return true
+	}
+	switch prev := lexemes[i-1]; prev {
+	case "|", "||", "&&", ";", "sudo", "doas", "exec", "nohup", "time", "xargs":
+		return true
+	default:
+		return strings.HasSuffix(prev, ";")
+	}
+}
+
+// gitOptionsWithValue are the global options of git taking the next argument
+// as their value, e.g. `git -C repo push`
+var gitOptionsWithValue = map[string]bool{
+	"-C": true, "-c": true, "--git-dir": true, "--work-tree": true,
+	"--namespace": true, "--super-prefix": true, "--config-env": true,
+}
+
+// gitSubcommand returns the index of the subcommand in the git arguments
+// args, skipping git's global options, or -1 if there is none.
+func gitSubcommand(args []string) int {
+	for i := 0; i < len(args); i++ {
+		switch {
+		case gitOptionsWithValue[args[i]]:
+			i++ // skip the value
+		case strings.HasPrefix(args[i], "-"):
+			// e.g. --no-pager or --git-dir=.git
+		default:
+			return i
+		}
+	}
+	return -1
+}
+
+// isRecursiveForce reports whether the rm arguments include both the recursive
+// and the force flag, e.g. `-rf`, `-r -f` or `--recursive --force`.
+func isRecursiveForce(args []string) bool {
+	recursive, force := false, false
+	for _, arg := range args {
+		switch {
+		case arg == "--":
+			return recursive && force
+		case arg == "--recursive":
+			recursive = true
+		case arg == "--force":
+			force = true
+		case strings.HasPrefix(arg, "-") && !strings.HasPrefix(arg, "--"):
+			recursive = recursive || strings.ContainsAny(arg, "rR")
+			force = force || strings.ContainsRune(arg, 'f')
+		}
+	}
+	return recursive && force
+}
@@ -0,0 +1,44 @@
+package utils
+
+import "testing"
+
+func TestIsDangerous(t *testing.T) {
+	dangerous := []string{
+		`rm -rf /tmp/foo`,
+		`rm -r -f build`,
+		`sudo rm -fR /`,
+		`rm --recursive --force build`,
+		`dd if=image.iso of=/dev/sdb bs=4M`,
+		`sudo mkfs.ext4 /dev/sdb1`,
+		`git push --force origin master`,
+		`git push -f`,
+		`git push origin +master`,
+		`git push --force-with-lease origin feature`,
+		`git push origin --delete v1.0`,
+		`git -C repo push --force`,
+		`git --git-dir=x push -f`,
+		`git -c k=v push origin +main`,
+		`git --no-pager -C repo push --force-with-lease`,
+	}
+	for _, cmd := range dangerous {
+		if !IsDangerous(cmd) {
+			t.Errorf("expected '%s' to be dangerous", cmd)
+		}
+	}
+
+	safe := []string{
+		`rm foo.txt`,
+		`rm -r build`,
+		`git push origin master`,
+		`git -C repo push origin main`,
+		`git -c push.default=current status`,
+		`git log --graph --decorate --oneline`,
+		`echo dd`,
+		`uname -a`,
+	}
+	for _, cmd := range safe {
+		if IsDangerous(cmd) {
+			t.Errorf("expected '%s' to be safe", cmd)
+		}
+	}
+}
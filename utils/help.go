@@ -0,0 +1,73 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/google/shlex"
+)
+
+// Binary returns the program run by cmd, skipping leading environment
+// variable assignments, e.g. "make" for "CC=clang make all".
+func Binary(cmd string) (string, bool) {
+	args, err := shlex.Split(cmd)
+	if err != nil {
+		args = strings.Fields(cmd)
+	}
+	for _, arg := range args {
+		if strings.Contains(arg, "=") && !strings.HasPrefix(arg, "=") {
+			continue
+		}
+		if strings.Contains(arg, "%(") {
+			// the binary is itself a variable
+			return "", false
+		}
+		return arg, true
+	}
+	return "", false
+}
+
+// Help returns the first maxLines lines of the man page of binary, which
+// must be the name of a program on PATH. The program itself is never run:
+// commands may come from an untrusted project, and not every program treats
+// --help as harmless.
+func Help(ctx context.Context, binary string, maxLines int) (string, error) {
+	if strings.ContainsRune(binary, '/') || strings.ContainsRune(binary, os.PathSeparator) {
+		return "", errors.New("not a program on PATH")
+	}
+	if _, err := exec.LookPath(binary); err != nil {
+		return "", err
+	}
+	if _, err := exec.LookPath("man"); err != nil {
+		return "", errors.New("man is not installed")
+	}
+	man := exec.CommandContext(ctx, "man", "-P", "cat", binary)
+	man.Env = append(os.Environ(), "MANWIDTH=80")
+	out, err := man.Output()
+	if ctx.Err() != nil {
+		return "", ctx.Err()
+	}
+	if err != nil || len(bytes.TrimSpace(out)) == 0 {
+		return "", errors.New("no man page")
+	}
+	return firstLines(out, maxLines), nil
+}
+
+// firstLines returns the first n lines of out which are not blank.
+func firstLines(out []byte, n int) string {
+	lines := make([]string, 0, n)
+	for _, line := range strings.Split(string(out), "\n") {
+		if len(lines) == n {
+			break
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		lines = append(lines, strings.TrimRight(line, " \t\r"))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,68 @@
+package utils
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBinary(t *testing.T) {
+	cases := map[string]string{
+		"make test":           "make",
+		"CC=clang make all":   "make",
+		"git log --oneline":   "git",
+		"%(editor) README.md": "",
+		"ssh -i key %(host)":  "ssh",
+	}
+	for cmd, expected := range cases {
+		binary, found := Binary(cmd)
+		if found != (expected != "") || binary != expected {
+			t.Errorf("'%s': expected '%s', got '%s'", cmd, expected, binary)
+		}
+	}
+}
+
+func TestHelpSkipsPaths(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spellbook")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	// a script which does not handle --help, and must not be run
+	ran := filepath.Join(dir, "ran")
+	script := filepath.Join(dir, "deploy.sh")
+	if err := ioutil.WriteFile(script, []byte("#!/bin/sh\ntouch "+ran+"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, binary := range []string{script, "./deploy.sh"} {
+		if _, err := Help(context.Background(), binary, 5); err == nil {
+			t.Errorf("'%s': expected no help for a path", binary)
+		}
+	}
+	if _, err := os.Stat(ran); err == nil {
+		t.Error("expected the script not to be run")
+	}
+}
+
+func TestHelpNeverRunsProgram(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spellbook")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	// a program on PATH without a man page, which must not be run for its --help
+	ran := filepath.Join(dir, "ran")
+	program := filepath.Join(dir, "spellbook-test-deploy")
+	if err := ioutil.WriteFile(program, []byte("#!/bin/sh\ntouch "+ran+"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	setenv(t, "PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	if _, err := Help(context.Background(), "spellbook-test-deploy", 5); err == nil {
+		t.Error("expected no help without a man page")
+	}
+	if _, err := os.Stat(ran); err == nil {
+		t.Error("expected the program not to be run")
+	}
+}
@@ -0,0 +1,94 @@
+package utils
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const historyFile = "history.json"
+
+// ExitCodeUnknown is the exit code recorded for commands whose exit code
+// spellbook never learns, e.g. commands run with --exec.
+const ExitCodeUnknown = -1
+
+// HistoryEntry records when a command was last run, and how that went.
+type HistoryEntry struct {
+	LastRun  time.Time `json:"last_run"`
+	ExitCode int       `json:"exit_code"`
+	Runs     int       `json:"runs"`
+}
+
+// History records the runs of each command, by command text.
+type History struct {
+	Entries map[string]HistoryEntry `json:"entries"`
+}
+
+func historyPath() (string, error) {
+	dir, err := StateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, historyFile), nil
+}
+
+// LoadHistory loads the history of commands run, which is empty if no
+// command has been run before.
+func LoadHistory() (*History, error) {
+	h := &History{Entries: make(map[string]HistoryEntry)}
+	path, err := historyPath()
+	if err != nil {
+		return h, err
+	}
+	bs, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return h, nil
+	} else if err != nil {
+		return h, err
+	}
+	if err := json.Unmarshal(bs, h); err != nil {
+		return h, &ConfigError{path, "cannot unmarshal", err}
+	}
+	if h.Entries == nil {
+		h.Entries = make(map[string]HistoryEntry)
+	}
+	return h, nil
+}
+
+// Last returns the last run of command.
+func (h *History) Last(command *Command) (HistoryEntry, bool) {
+	entry, found := h.Entries[command.Text()]
+	return entry, found
+}
+
+// Record records a run of command which exited with exitCode.
+func (h *History) Record(command *Command, exitCode int) {
+	entry := h.Entries[command.Text()]
+	entry.LastRun = time.Now()
+	entry.ExitCode = exitCode
+	entry.Runs++
+	h.Entries[command.Text()] = entry
+}
+
+// Save writes the history, keeping the runs other spellbooks saved since it
+// was loaded: of two entries for a command, the one run last is kept.
+func (h *History) Save() error {
+	path, err := historyPath()
+	if err != nil {
+		return err
+	}
+	if saved, err := LoadHistory(); err == nil {
+		for text, entry := range saved.Entries {
+			if current, found := h.Entries[text]; !found || entry.LastRun.After(current.LastRun) {
+				h.Entries[text] = entry
+			}
+		}
+	}
+	bs, err := json.MarshalIndent(h, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, bs)
+}
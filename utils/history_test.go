@@ -0,0 +1,59 @@
+package utils
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestHistory_SaveLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spellbook")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	setenv(t, "XDG_STATE_HOME", dir)
+
+	h, err := LoadHistory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmd := &Command{Cmd: "make %(target)"}
+	if _, found := h.Last(cmd); found {
+		t.Fatal("expected empty history")
+	}
+	h.Record(cmd, 0)
+	h.Record(cmd, 2)
+	if err := h.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadHistory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry, found := loaded.Last(cmd)
+	if !found {
+		t.Fatal("expected command in history")
+	}
+	if entry.Runs != 2 || entry.ExitCode != 2 || entry.LastRun.IsZero() {
+		t.Errorf("unexpected entry %+v", entry)
+	}
+
+	// another spellbook, started before the runs above were saved
+	other := &History{Entries: make(map[string]HistoryEntry)}
+	other.Record(&Command{Cmd: "ls"}, 0)
+	if err := other.Save(); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err = LoadHistory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, found := loaded.Last(cmd); !found {
+		t.Error("expected the runs saved earlier to be kept")
+	}
+	if _, found := loaded.Last(&Command{Cmd: "ls"}); !found {
+		t.Error("expected the new run saved")
+	}
+}
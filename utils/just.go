@@ -0,0 +1,216 @@
+package utils
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+)
+
+func init() {
+	RegisterSource("just", justSources)
+}
+
+// justfileNames are the names just looks for
+var justfileNames = []string{"justfile", "Justfile", ".justfile"}
+
+// JustParam is a parameter of a justfile recipe, e.g. `target='debug'`
+type JustParam struct {
+	Name    string
+	Default string
+	// Variadic parameters take several arguments, e.g. `+files` or `*flags`
+	Variadic bool
+	// ZeroOrMore is set for variadic parameters which take no arguments too, e.g. `*flags`
+	ZeroOrMore bool
+}
+
+// Optional reports whether the parameter may be left out.
+func (p JustParam) Optional() bool {
+	return p.Default != "" || p.ZeroOrMore
+}
+
+// JustRecipe is a recipe of a justfile, described by the comment on the
+// line before it.
+type JustRecipe struct {
+	Name   string
+	Doc    string
+	Params []JustParam
+}
+
+// ParseJustfile returns the public recipes of a justfile, in order of
+// appearance, leaving out those named with a leading _ or marked [private].
+func ParseJustfile(r io.Reader) ([]JustRecipe, error) {
+	var recipes []JustRecipe
+	doc := ""
+	private := false
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "" || unicode.IsSpace(rune(line[0])):
+			// the body of a recipe, or a blank line
+		case strings.HasPrefix(trimmed, "#"):
+			doc = strings.TrimSpace(strings.TrimPrefix(trimmed, "#"))
+			continue
+		case strings.HasPrefix(trimmed, "["):
+			// attributes, e.g. [private] or [doc('build the binary')]
+			private = private || strings.Contains(trimmed, "private")
+			if attrDoc, found := justDocAttribute(trimmed); found {
+				doc = attrDoc
+			}
+			continue
+		default:
+			if recipe, ok := parseRecipe(line); ok && !private && !strings.HasPrefix(recipe.Name, "_") {
+				recipe.Doc = doc
+				recipes = append(recipes, recipe)
+			}
+		}
+		doc = ""
+		private = false
+	}
+	return recipes, scanner.Err()
+}
+
+// justDocAttribute returns the documentation of a doc attribute, e.g.
+// [doc('build the binary')].
+func justDocAttribute(attrs string) (string, bool) {
+	i := strings.Index(attrs, "doc(")
+	if i == -1 || len(attrs) < i+5 {
+		return "", false
+	}
+	rest := attrs[i+4:]
+	if quote := rest[0]; quote == '\'' || quote == '"' {
+		if end := strings.IndexByte(rest[1:], quote); end != -1 {
+			return rest[1 : end+1], true
+		}
+	}
+	return "", false
+}
+
+// parseRecipe parses the header of a recipe, e.g. `@build target='debug': deps`.
+func parseRecipe(line string) (JustRecipe, bool) {
+	fields, rest := splitHeader(line)
+	// settings, aliases and assignments, e.g. `set shell := ["bash"]` or `version := "1"`
+	if len(fields) == 0 || !strings.HasPrefix(rest, ":") || strings.HasPrefix(rest, ":=") {
+		return JustRecipe{}, false
+	}
+	switch fields[0] {
+	case "set", "alias", "export", "import", "mod":
+		return JustRecipe{}, false
+	}
+	recipe := JustRecipe{Name: strings.TrimPrefix(fields[0], "@")}
+	for _, field := range fields[1:] {
+		var param JustParam
+		if strings.HasPrefix(field, "+") || strings.HasPrefix(field, "*") {
+			param.Variadic = true
+			param.ZeroOrMore = field[0] == '*'
+			field = field[1:]
+		}
+		field = strings.TrimPrefix(field, "$") // exported as an environment variable
+		parts := strings.SplitN(field, "=", 2)
+		param.Name = parts[0]
+		if len(parts) == 2 {
+			param.Default = strings.Trim(parts[1], `'"`)
+		}
+		recipe.Params = append(recipe.Params, param)
+	}
+	return recipe, true
+}
+
+// splitHeader splits line at spaces up to the first colon outside quotes and
+// parentheses, returning the fields and the rest of line from the colon.
+func splitHeader(line string) ([]string, string) {
+	var fields []string
+	var field strings.Builder
+	quote := rune(0)
+	depth := 0
+	for i, ch := range line {
+		switch {
+		case quote != 0:
+			if ch == quote {
+				quote = 0
+			}
+		case ch == '\'' || ch == '"' || ch == '`':
+			quote = ch
+		case ch == '(':
+			depth++
+		case ch == ')':
+			depth--
+		case depth == 0 && (ch == ':' || ch == ' ' || ch == '\t'):
+			if field.Len() != 0 {
+				fields = append(fields, field.String())
+				field.Reset()
+			}
+			if ch == ':' {
+				return fields, line[i:]
+			}
+			continue
+		}
+		field.WriteRune(ch)
+	}
+	return nil, ""
+}
+
+// JustSource is the recipes of a justfile, run as `just <recipe>` with a
+// variable for each parameter up to the first optional one. Parameters are
+// positional, so a variable left empty for an optional parameter would pass
+// the next argument in its place.
+type JustSource struct {
+	// Path is the absolute path of the justfile
+	Path string
+}
+
+func (s *JustSource) Name() string {
+	return "just"
+}
+
+func (s *JustSource) Load() ([]Command, error) {
+	f, err := os.Open(s.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	recipes, err := ParseJustfile(f)
+	if err != nil {
+		return nil, err
+	}
+	commands := make([]Command, len(recipes))
+	for i, recipe := range recipes {
+		cmd := "just " + EscapeTemplate(recipe.Name)
+		vars := make(map[string]string)
+		for _, param := range recipe.Params {
+			if param.Optional() {
+				break
+			}
+			cmd += fmt.Sprintf(" %%(%s)", param.Name)
+			if param.Variadic {
+				vars[param.Name] = "one or more arguments, separated by spaces"
+			}
+		}
+		commands[i] = Command{
+			Cmd:    cmd,
+			Desc:   recipe.Doc,
+			Cwd:    CwdConfigDir,
+			Vars:   vars,
+			Tags:   []string{"just"},
+			Source: s.Path,
+		}
+	}
+	return commands, nil
+}
+
+// Watch calls changed each time the justfile is saved.
+func (s *JustSource) Watch(changed func(), done <-chan struct{}) {
+	watchFiles([]string{s.Path}, changed, done)
+}
+
+// justSources returns a source for the justfile of the working directory and
+// one for the justfile of the project root, if these exist.
+func justSources(conf *Config) []CommandSource {
+	return fileSources(justfileNames, func(path string) CommandSource {
+		return &JustSource{path}
+	})
+}
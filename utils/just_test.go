@@ -0,0 +1,68 @@
+package utils
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testJustfile = `set dotenv-load
+version := "1.0"
+alias b := build
+
+# build the binary
+@build target='debug':
+    cargo build --profile {{target}}
+
+# deploy to an environment
+deploy env +hosts: build
+    ./deploy.sh {{env}} {{hosts}}
+
+[private]
+helper:
+    echo hidden
+
+_internal:
+    echo hidden
+
+[doc('run the tests')]
+test $RUST_LOG="info" *flags:
+    cargo test {{flags}}
+`
+
+func TestParseJustfile(t *testing.T) {
+	recipes, err := ParseJustfile(strings.NewReader(testJustfile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []JustRecipe{
+		{"build", "build the binary", []JustParam{{Name: "target", Default: "debug"}}},
+		{"deploy", "deploy to an environment", []JustParam{{Name: "env"}, {Name: "hosts", Variadic: true}}},
+		{"test", "run the tests", []JustParam{{Name: "RUST_LOG", Default: "info"}, {Name: "flags", Variadic: true, ZeroOrMore: true}}},
+	}
+	if !reflect.DeepEqual(recipes, expected) {
+		t.Errorf("expected %v, got %v", expected, recipes)
+	}
+}
+
+func TestJustSourceVariables(t *testing.T) {
+	source := &JustSource{writeTemp(t, "justfile", testJustfile)}
+	commands, err := source.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(commands) != 3 {
+		t.Fatalf("expected 3 commands, got %v", commands)
+	}
+	if commands[1].Cmd != "just deploy %(env) %(hosts)" {
+		t.Errorf("expected a variable per parameter, got '%s'", commands[1].Cmd)
+	}
+	names, err := commands[1].VarNames()
+	if err != nil || !reflect.DeepEqual(names, []string{"env", "hosts"}) {
+		t.Errorf("expected the parameters to be completed as variables, got %v (%v)", names, err)
+	}
+	// optional parameters are left out, an empty one would shift the arguments after it
+	if commands[0].Cmd != "just build" || commands[2].Cmd != "just test" {
+		t.Errorf("expected no variables for optional parameters, got '%s' and '%s'", commands[0].Cmd, commands[2].Cmd)
+	}
+}
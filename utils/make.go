@@ -0,0 +1,240 @@
+package utils
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+func init() {
+	RegisterSource("make", makeSources)
+}
+
+// makefileNames are the names make looks for, in the order it looks for them
+var makefileNames = []string{"GNUmakefile", "makefile", "Makefile"}
+
+const makeCacheFile = "makefiles.json"
+
+// MakeTarget is a target of a Makefile, described by a `## comment` after
+// the target or on the line before it.
+type MakeTarget struct {
+	Name string `json:"name"`
+	Desc string `json:"desc"`
+}
+
+// ParseMakefile returns the targets of a Makefile, in order of appearance,
+// leaving out special targets such as .PHONY, pattern rules and targets
+// named by variables.
+func ParseMakefile(r io.Reader) ([]MakeTarget, error) {
+	var targets []MakeTarget
+	seen := make(map[string]int)
+	desc := "" // of a `## comment` line, for the target on the next line
+	inDefine := false
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		// join continued lines
+		for strings.HasSuffix(line, "\\") && scanner.Scan() {
+			line = strings.TrimSuffix(line, "\\") + " " + strings.TrimSpace(scanner.Text())
+		}
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case inDefine:
+			inDefine = trimmed != "endef"
+			continue
+		case strings.HasPrefix(trimmed, "define ") || trimmed == "define":
+			inDefine = true
+			continue
+		case strings.HasPrefix(line, "\t"):
+			// a recipe
+			continue
+		case strings.HasPrefix(trimmed, "##"):
+			desc = strings.TrimSpace(strings.TrimLeft(trimmed, "#"))
+			continue
+		}
+
+		names, lineDesc, isRule := parseRule(line)
+		if lineDesc != "" {
+			desc = lineDesc
+		}
+		if isRule && len(names) == 0 {
+			// e.g. .PHONY, keep the description for the target after it
+			continue
+		}
+		if isRule {
+			for _, name := range names {
+				if i, found := seen[name]; found {
+					if targets[i].Desc == "" {
+						targets[i].Desc = desc
+					}
+					continue
+				}
+				seen[name] = len(targets)
+				targets = append(targets, MakeTarget{name, desc})
+			}
+		}
+		desc = ""
+	}
+	return targets, scanner.Err()
+}
+
+// parseRule returns the targets of line if it is a rule, e.g. "build test:
+// deps ## description", and the description of its `## comment`.
+func parseRule(line string) (names []string, desc string, isRule bool) {
+	if i := strings.Index(line, "##"); i != -1 {
+		desc = strings.TrimSpace(strings.TrimLeft(line[i:], "#"))
+		line = line[:i]
+	}
+	if i := strings.Index(line, "#"); i != -1 {
+		line = line[:i]
+	}
+	colon := strings.Index(line, ":")
+	// variables are assigned with =, :=, ::=, ?= and the like
+	if colon == -1 || strings.Contains(line[:colon], "=") || strings.Contains(line[colon:], "=") {
+		return nil, "", false
+	}
+	// a colon within a function call, e.g. $(call f,a:b), is not a rule's
+	open := strings.Count(line[:colon], "(") + strings.Count(line[:colon], "{")
+	if open != strings.Count(line[:colon], ")")+strings.Count(line[:colon], "}") {
+		return nil, "", false
+	}
+	for _, name := range strings.Fields(line[:colon]) {
+		if strings.HasPrefix(name, ".") || strings.ContainsAny(name, "%$*?") {
+			continue
+		}
+		names = append(names, name)
+	}
+	return names, desc, true
+}
+
+// MakeSource is the targets of a Makefile, run as `make <target>` in the
+// directory of the Makefile.
+type MakeSource struct {
+	// Path is the absolute path of the Makefile
+	Path string
+}
+
+func (s *MakeSource) Name() string {
+	return "make"
+}
+
+func (s *MakeSource) Load() ([]Command, error) {
+	targets, err := cachedMakeTargets(s.Path)
+	if err != nil {
+		return nil, err
+	}
+	commands := make([]Command, len(targets))
+	for i, target := range targets {
+		commands[i] = Command{
+			Cmd:    "make " + target.Name,
+			Desc:   target.Desc,
+			Cwd:    CwdConfigDir,
+			Tags:   []string{"make"},
+			Source: s.Path,
+		}
+	}
+	return commands, nil
+}
+
+// Watch calls changed each time the Makefile is saved.
+func (s *MakeSource) Watch(changed func(), done <-chan struct{}) {
+	watchFiles([]string{s.Path}, changed, done)
+}
+
+// makeSources returns a source for the Makefile of the working directory and
+// one for the Makefile of the project root, if these exist.
+func makeSources(conf *Config) []CommandSource {
+	return fileSources(makefileNames, func(path string) CommandSource {
+		return &MakeSource{path}
+	})
+}
+
+// makeCacheEntry holds the targets of a Makefile as it was when last parsed
+type makeCacheEntry struct {
+	ModTime time.Time    `json:"mod_time"`
+	Size    int64        `json:"size"`
+	Targets []MakeTarget `json:"targets"`
+}
+
+func makeCachePath() (string, error) {
+	dir, err := StateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, makeCacheFile), nil
+}
+
+// makeCacheMu serializes the updates of the cache, as the Makefiles of the
+// current directory and of the project root are loaded at the same time
+var makeCacheMu sync.Mutex
+
+// cachedMakeTargets returns the targets of the Makefile at path, parsing it
+// only if it changed since it was last parsed. Failing to use the cache
+// only makes this slower.
+func cachedMakeTargets(path string) ([]MakeTarget, error) {
+	makeCacheMu.Lock()
+	defer makeCacheMu.Unlock()
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	cache := make(map[string]makeCacheEntry)
+	cachePath, cacheErr := makeCachePath()
+	if cacheErr == nil {
+		if bs, err := ioutil.ReadFile(cachePath); err == nil {
+			json.Unmarshal(bs, &cache)
+		}
+	}
+	if entry, found := cache[path]; found && entry.ModTime.Equal(info.ModTime()) && entry.Size == info.Size() {
+		return entry.Targets, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	targets, err := ParseMakefile(f)
+	if err != nil {
+		return nil, err
+	}
+	if cacheErr == nil {
+		cache[path] = makeCacheEntry{info.ModTime(), info.Size(), targets}
+		// forget the Makefiles which are gone
+		for cached := range cache {
+			if _, err := os.Stat(cached); os.IsNotExist(err) {
+				delete(cache, cached)
+			}
+		}
+		if bs, err := json.Marshal(cache); err == nil {
+			writeFileAtomic(cachePath, bs)
+		}
+	}
+	return targets, nil
+}
+
+// writeFileAtomic writes data to a temporary file renamed to path, so that
+// another spellbook reading path never reads it half written.
+func writeFileAtomic(path string, data []byte) error {
+	f, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
@@ -0,0 +1,146 @@
+package utils
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const testMakefile = `GO ?= go
+BIN := bin/app
+VERSION = $(shell git describe:--tags)
+
+.PHONY: build test lint
+
+## build the binary
+.PHONY: build
+build: $(BIN)
+
+$(BIN): main.go
+	$(GO) build -o $@ .
+
+test: build ## run the tests
+	$(GO) test ./...
+
+lint fmt: # not described
+	golangci-lint run
+
+%.o: %.c
+	cc -c $<
+
+$(call rule,a:b)
+
+define RECIPE
+fake: target
+endef
+
+docker-build \
+	docker-push: ## build and push the image
+	docker build .
+
+test::
+	@echo again
+`
+
+func TestParseMakefile(t *testing.T) {
+	targets, err := ParseMakefile(strings.NewReader(testMakefile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []MakeTarget{
+		{"build", "build the binary"},
+		{"test", "run the tests"},
+		{"lint", ""},
+		{"fmt", ""},
+		{"docker-build", "build and push the image"},
+		{"docker-push", "build and push the image"},
+	}
+	if !reflect.DeepEqual(targets, expected) {
+		t.Errorf("expected %v, got %v", expected, targets)
+	}
+}
+
+func TestMakeSource(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spellbook")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	setenv(t, "XDG_STATE_HOME", dir)
+
+	path := filepath.Join(dir, "Makefile")
+	if err := ioutil.WriteFile(path, []byte("test: ## run the tests\n\tgo test\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	source := &MakeSource{path}
+	commands, err := source.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(commands) != 1 || commands[0].Cmd != "make test" || commands[0].Desc != "run the tests" {
+		t.Fatalf("unexpected commands %v", commands)
+	}
+	if workDir, _ := commands[0].WorkDir(); workDir != dir {
+		t.Errorf("expected the command to run in '%s', got '%s'", dir, workDir)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "spellbook", makeCacheFile)); err != nil {
+		t.Errorf("expected the targets to be cached: %v", err)
+	}
+
+	// a changed Makefile is parsed again
+	if err := ioutil.WriteFile(path, []byte("test:\n\tgo test\nlint:\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if commands, _ = source.Load(); len(commands) != 2 {
+		t.Errorf("expected the targets of the changed Makefile, got %v", commands)
+	}
+}
+
+func TestCachedMakeTargetsConcurrent(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spellbook")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	setenv(t, "XDG_STATE_HOME", dir)
+
+	var paths []string
+	for _, name := range []string{"a", "b", "c", "d"} {
+		paths = append(paths, writeTemp(t, "Makefile", name+":\n"))
+	}
+	var wg sync.WaitGroup
+	for _, path := range paths {
+		wg.Add(1)
+		go func(path string) {
+			defer wg.Done()
+			cachedMakeTargets(path)
+		}(path)
+	}
+	wg.Wait()
+
+	bs, err := ioutil.ReadFile(filepath.Join(dir, "spellbook", makeCacheFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cache map[string]makeCacheEntry
+	if err := json.Unmarshal(bs, &cache); err != nil {
+		t.Fatal(err)
+	}
+	if len(cache) != len(paths) {
+		t.Errorf("expected every Makefile to be cached, got %v", cache)
+	}
+}
+
+func TestSourcesDisabled(t *testing.T) {
+	conf := &Config{Files: []string{"/projects/foo/.spellbook.yml"}, Sources: map[string]bool{"yaml": false}}
+	for _, source := range Sources(conf) {
+		if _, ok := source.(*YAMLSource); ok {
+			t.Errorf("expected no config file sources once disabled")
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func init() {
+	RegisterSource("npm", npmSources)
+}
+
+// packageManagers are the package managers running scripts, by lockfile, in
+// the order they are looked for
+var packageManagers = []struct {
+	name     string
+	lockfile string
+}{
+	{"pnpm", "pnpm-lock.yaml"},
+	{"yarn", "yarn.lock"},
+	{"npm", "package-lock.json"},
+}
+
+// PackageScript is a script of a package.json
+type PackageScript struct {
+	Name   string
+	Script string
+}
+
+// packageJSON is the part of a package.json read, its scripts are read in
+// order by ParsePackageScripts
+type packageJSON struct {
+	// PackageManager is e.g. "pnpm@8.6.0"
+	PackageManager string          `json:"packageManager"`
+	Scripts        json.RawMessage `json:"scripts"`
+}
+
+// ParsePackageScripts returns the scripts of a package.json, in order, and
+// the package manager it asks for with `packageManager`, if any.
+func ParsePackageScripts(r io.Reader) ([]PackageScript, string, error) {
+	var pkg packageJSON
+	if err := json.NewDecoder(r).Decode(&pkg); err != nil {
+		return nil, "", err
+	}
+	manager := strings.SplitN(pkg.PackageManager, "@", 2)[0]
+	if len(pkg.Scripts) == 0 {
+		return nil, manager, nil
+	}
+	// a map would lose the order of the scripts
+	dec := json.NewDecoder(bytes.NewReader(pkg.Scripts))
+	if tok, err := dec.Token(); err != nil {
+		return nil, "", err
+	} else if tok != json.Delim('{') {
+		return nil, "", fmt.Errorf("expected scripts to be an object, got %v", tok)
+	}
+	var scripts []PackageScript
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, "", err
+		}
+		var script string
+		if err := dec.Decode(&script); err != nil {
+			return nil, "", err
+		}
+		scripts = append(scripts, PackageScript{tok.(string), script})
+	}
+	return scripts, manager, nil
+}
+
+// packageManager returns the package manager of the package in dir, found
+// by its lockfile, or npm if there is none.
+func packageManager(dir string) string {
+	for _, pm := range packageManagers {
+		if _, err := os.Stat(filepath.Join(dir, pm.lockfile)); err == nil {
+			return pm.name
+		}
+	}
+	return "npm"
+}
+
+// NPMSource is the scripts of a package.json, run with the package manager
+// the package uses, e.g. `yarn run build`.
+type NPMSource struct {
+	// Path is the absolute path of the package.json
+	Path string
+}
+
+func (s *NPMSource) Name() string {
+	return "npm"
+}
+
+func (s *NPMSource) Load() ([]Command, error) {
+	bs, err := ioutil.ReadFile(s.Path)
+	if err != nil {
+		return nil, err
+	}
+	scripts, manager, err := ParsePackageScripts(bytes.NewReader(bs))
+	if err != nil {
+		return nil, err
+	}
+	if manager == "" {
+		manager = packageManager(filepath.Dir(s.Path))
+	}
+	commands := make([]Command, len(scripts))
+	for i, script := range scripts {
+		commands[i] = Command{
+			Cmd:    EscapeTemplate(manager + " run " + script.Name),
+			Desc:   script.Script,
+			Cwd:    CwdConfigDir,
+			Tags:   []string{manager},
+			Source: s.Path,
+		}
+	}
+	return commands, nil
+}
+
+// Watch calls changed each time the package.json is saved, or a lockfile
+// is added or removed.
+func (s *NPMSource) Watch(changed func(), done <-chan struct{}) {
+	paths := []string{s.Path}
+	for _, pm := range packageManagers {
+		paths = append(paths, filepath.Join(filepath.Dir(s.Path), pm.lockfile))
+	}
+	watchFiles(paths, changed, done)
+}
+
+// npmSources returns a source for the package.json of the working directory
+// and one for the package.json of the project root, if these exist.
+func npmSources(conf *Config) []CommandSource {
+	return fileSources([]string{"package.json"}, func(path string) CommandSource {
+		return &NPMSource{path}
+	})
+}
@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParsePackageScripts(t *testing.T) {
+	pkg := `{
+  "name": "app",
+  "scripts": {
+    "test": "jest",
+    "build": "tsc -p .",
+    "build:prod": "NODE_ENV=production tsc -p ."
+  },
+  "packageManager": "pnpm@8.6.0"
+}`
+	scripts, manager, err := ParsePackageScripts(strings.NewReader(pkg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []PackageScript{
+		{"test", "jest"},
+		{"build", "tsc -p ."},
+		{"build:prod", "NODE_ENV=production tsc -p ."},
+	}
+	if !reflect.DeepEqual(scripts, expected) {
+		t.Errorf("expected the scripts in order %v, got %v", expected, scripts)
+	}
+	if manager != "pnpm" {
+		t.Errorf("expected pnpm, got '%s'", manager)
+	}
+}
+
+func TestNPMSource(t *testing.T) {
+	path := writeTemp(t, "package.json", `{"scripts": {"lint": "eslint --ext %s ."}}`)
+	dir := filepath.Dir(path)
+
+	source := &NPMSource{path}
+	commands, err := source.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(commands) != 1 || commands[0].Cmd != "npm run lint" || commands[0].Desc != "eslint --ext %s ." {
+		t.Errorf("expected npm without a lockfile, got %v", commands)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "yarn.lock"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if commands, _ = source.Load(); len(commands) != 1 || commands[0].Cmd != "yarn run lint" {
+		t.Errorf("expected yarn with a yarn.lock, got %v", commands)
+	}
+}
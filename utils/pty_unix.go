@@ -0,0 +1,46 @@
+// +build !windows
+
+package utils
+
+import (
+	"context"
+	"io"
+	"os/exec"
+
+	"github.com/creack/pty"
+)
+
+// RunCaptured runs cmd in directory dir attached to a pseudo-terminal of the
+// given size, copying everything it writes to out.
+// The command is killed if ctx is cancelled.
+func RunCaptured(ctx context.Context, cmd string, dir string, out io.Writer, cols int, rows int) error {
+	lexemes, err := splitCommand(cmd)
+	if err != nil {
+		return err
+	}
+
+	c := exec.CommandContext(ctx, lexemes[0], lexemes[1:]...)
+	c.Dir = dir
+	c.Env = capturedEnv()
+	ptmx, err := pty.StartWithSize(c, &pty.Winsize{Cols: uint16(cols), Rows: uint16(rows)})
+	if err != nil {
+		return err
+	}
+	defer ptmx.Close()
+
+	// unblock the copy below if the command is killed while
+	// its children are still holding on to the terminal
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			ptmx.Close()
+		case <-done:
+		}
+	}()
+
+	// reading fails (EIO) once the command has exited and closed the terminal
+	_, _ = io.Copy(out, ptmx)
+	return c.Wait()
+}
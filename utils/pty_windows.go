@@ -0,0 +1,25 @@
+package utils
+
+import (
+	"context"
+	"io"
+	"os/exec"
+)
+
+// RunCaptured runs cmd in directory dir, copying everything it writes to out.
+// Pseudo-terminals are not supported on windows, so the command's output is
+// read from pipes and cols and rows are ignored.
+// The command is killed if ctx is cancelled.
+func RunCaptured(ctx context.Context, cmd string, dir string, out io.Writer, cols int, rows int) error {
+	lexemes, err := splitCommand(cmd)
+	if err != nil {
+		return err
+	}
+
+	c := exec.CommandContext(ctx, lexemes[0], lexemes[1:]...)
+	c.Dir = dir
+	c.Env = capturedEnv()
+	c.Stdout = out
+	c.Stderr = out
+	return c.Run()
+}
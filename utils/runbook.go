@@ -0,0 +1,152 @@
+package utils
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+	"unicode/utf8"
+)
+
+const runbookFile = "runbook.json"
+
+// maxStepOutput is the number of bytes of output kept for each runbook step
+const maxStepOutput = 32 * 1024
+
+// Runbook tracks the progress of working through a multi-step command one step at a time.
+type Runbook struct {
+	Title   string            `json:"title"`
+	Dir     string            `json:"dir"`
+	Values  map[string]string `json:"values"`
+	Steps   []RunbookStep     `json:"steps"`
+	Started time.Time         `json:"started"`
+	// Confirm requires each step to be confirmed before it is run
+	Confirm bool `json:"confirm"`
+}
+
+// RunbookStep is a step of a runbook and the outcome of its last run
+type RunbookStep struct {
+	Cmd      string    `json:"cmd"`
+	Done     bool      `json:"done"`
+	ExitCode int       `json:"exit_code"`
+	Output   string    `json:"output,omitempty"`
+	RunAt    time.Time `json:"run_at,omitempty"`
+}
+
+// Ran returns true if the step has been run at least once.
+func (s *RunbookStep) Ran() bool {
+	return !s.RunAt.IsZero()
+}
+
+// NewRunbook creates a runbook for command, whose steps with values filled in are cmds.
+func NewRunbook(command *Command, cmds []string, values map[string]string, dir string) *Runbook {
+	title := command.Desc
+	if title == "" {
+		title = command.Text()
+	}
+	r := &Runbook{
+		Title:   title,
+		Dir:     dir,
+		Values:  values,
+		Steps:   make([]RunbookStep, len(cmds)),
+		Started: time.Now(),
+		Confirm: command.Confirm,
+	}
+	for i, cmd := range cmds {
+		r.Steps[i].Cmd = cmd
+	}
+	return r
+}
+
+// SameSteps reports whether the runbook runs cmds in directory dir, e.g. as
+// it was saved for the same command with the same values.
+func (r *Runbook) SameSteps(cmds []string, dir string) bool {
+	if r.Dir != dir || len(r.Steps) != len(cmds) {
+		return false
+	}
+	for i, step := range r.Steps {
+		if step.Cmd != cmds[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// NextStep returns the index of the first unfinished step, or -1 if all steps are done.
+func (r *Runbook) NextStep() int {
+	for i, step := range r.Steps {
+		if !step.Done {
+			return i
+		}
+	}
+	return -1
+}
+
+// Record stores the outcome of running step i.
+func (r *Runbook) Record(i int, err error, output string) {
+	if len(output) > maxStepOutput {
+		// keep the end of the output, starting at a whole character
+		cut := len(output) - maxStepOutput
+		for cut < len(output) && !utf8.RuneStart(output[cut]) {
+			cut++
+		}
+		output = output[cut:]
+	}
+	step := &r.Steps[i]
+	step.Done = err == nil
+	step.ExitCode = ExitCode(err)
+	step.Output = output
+	step.RunAt = time.Now()
+}
+
+func runbookPath() (string, error) {
+	dir, err := StateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, runbookFile), nil
+}
+
+// Save stores the runbook, replacing any previously saved runbook.
+func (r *Runbook) Save() error {
+	path, err := runbookPath()
+	if err != nil {
+		return err
+	}
+	bs, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, bs)
+}
+
+// LoadRunbook loads the saved runbook. The error satisfies os.IsNotExist if
+// there is no saved runbook.
+func LoadRunbook() (*Runbook, error) {
+	path, err := runbookPath()
+	if err != nil {
+		return nil, err
+	}
+	bs, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var r Runbook
+	if err := json.Unmarshal(bs, &r); err != nil {
+		return nil, &ConfigError{path, "cannot unmarshal", err}
+	}
+	return &r, nil
+}
+
+// RemoveRunbook removes the saved runbook, if any.
+func RemoveRunbook() error {
+	path, err := runbookPath()
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package utils
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestRunbook_SaveLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spellbook")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	setenv(t, "XDG_STATE_HOME", dir)
+
+	if _, err := LoadRunbook(); !os.IsNotExist(err) {
+		t.Fatalf("expected no runbook, got %v", err)
+	}
+
+	cmd := &Command{Desc: "release", Steps: []string{"make", "make dist", "make publish"}}
+	r := NewRunbook(cmd, cmd.Steps, map[string]string{"version": "1.0"}, "/tmp")
+	r.Record(0, nil, "built")
+	r.Record(1, errors.New("failed"), "oops")
+	if err := r.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadRunbook()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.NextStep() != 1 {
+		t.Errorf("expected to resume at step 1, got %d", loaded.NextStep())
+	}
+	if loaded.Values["version"] != "1.0" || loaded.Steps[0].Output != "built" || !loaded.Steps[1].Ran() {
+		t.Errorf("runbook not restored: %+v", loaded)
+	}
+	if !loaded.SameSteps(cmd.Steps, "/tmp") {
+		t.Error("expected the same steps as the command")
+	}
+	if loaded.SameSteps(cmd.Steps, "/src") || loaded.SameSteps(cmd.Steps[:2], "/tmp") {
+		t.Error("expected other steps for another directory or fewer steps")
+	}
+
+	if err := RemoveRunbook(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadRunbook(); !os.IsNotExist(err) {
+		t.Errorf("expected runbook to be removed, got %v", err)
+	}
+}
+
+func TestRunbook_RecordLongOutput(t *testing.T) {
+	rb := &Runbook{Steps: []RunbookStep{{Cmd: "cat log"}}}
+	// the cut would fall within the first 'ä', two bytes long
+	output := "ä" + strings.Repeat("a", maxStepOutput-4) + "end"
+	rb.Record(0, nil, output)
+	kept := rb.Steps[0].Output
+	if !utf8.ValidString(kept) || !strings.HasSuffix(kept, "end") || len(kept) > maxStepOutput {
+		t.Errorf("expected the end of the output cut at a character, got %d bytes, valid: %v",
+			len(kept), utf8.ValidString(kept))
+	}
+}
@@ -0,0 +1,230 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/mitchellh/go-homedir"
+	"github.com/spf13/viper"
+)
+
+// CommandSource provides commands to pick from, e.g. those of a config file.
+type CommandSource interface {
+	// Name labels the commands of the source, e.g. "project"
+	Name() string
+	// Load reads the commands of the source
+	Load() ([]Command, error)
+}
+
+// Watcher is implemented by command sources whose commands can change while
+// spellbook runs, e.g. as a file is edited.
+type Watcher interface {
+	// Watch calls changed each time the commands may have changed, until
+	// done is closed.
+	Watch(changed func(), done <-chan struct{})
+}
+
+// SourceFactory returns the command sources of one kind given the config
+// read, e.g. a source per config file. It returns none if the kind of
+// source does not apply.
+type SourceFactory func(conf *Config) []CommandSource
+
+type sourceKind struct {
+	name    string
+	factory SourceFactory
+}
+
+// sourceKinds are the kinds of command sources, in order of registration
+var sourceKinds = []sourceKind{
+	{"yaml", yamlSources},
+}
+
+// RegisterSource adds a kind of command source. Its sources come after
+// those of the kinds registered before.
+func RegisterSource(kind string, factory SourceFactory) {
+	for _, k := range sourceKinds {
+		if k.name == kind {
+			panic(fmt.Sprintf("command source '%s' registered twice", kind))
+		}
+	}
+	sourceKinds = append(sourceKinds, sourceKind{kind, factory})
+}
+
+// Sources returns the command sources of every kind registered for conf,
+// leaving out the kinds conf disables.
+func Sources(conf *Config) []CommandSource {
+	var sources []CommandSource
+	for _, kind := range sourceKinds {
+		if enabled, found := conf.Sources[kind.name]; found && !enabled {
+			continue
+		}
+		sources = append(sources, kind.factory(conf)...)
+	}
+	return sources
+}
+
+// SourceError is returned for a command source which failed to load
+type SourceError struct {
+	Source string
+	Cause  error
+}
+
+func (e *SourceError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Source, e.Cause)
+}
+
+// LoadCommands loads the commands of sources, in order, setting the
+// SourceName of each. Sources failing to load are left out, their errors
+// are returned alongside the commands of the others.
+func LoadCommands(sources []CommandSource) ([]Command, []error) {
+	var commands []Command
+	var errs []error
+	for _, source := range sources {
+		loaded, err := source.Load()
+		if err != nil {
+			errs = append(errs, &SourceError{source.Name(), err})
+			continue
+		}
+		for i := range loaded {
+			loaded[i].SourceName = source.Name()
+		}
+		commands = append(commands, loaded...)
+	}
+	return commands, errs
+}
+
+// WatchSources calls changed with each source implementing Watcher whose
+// commands may have changed, until done is closed. changed is called from
+// other goroutines.
+func WatchSources(sources []CommandSource, changed func(source CommandSource), done <-chan struct{}) {
+	for _, source := range sources {
+		if watcher, ok := source.(Watcher); ok {
+			source := source
+			go watcher.Watch(func() { changed(source) }, done)
+		}
+	}
+}
+
+// watchInterval is how often watched files are checked for changes
+var watchInterval = time.Second
+
+// watchFiles calls changed each time one of paths is modified, created or
+// removed, until done is closed. Sources read from files implement Watch
+// with it, polling every watchInterval.
+func watchFiles(paths []string, changed func(), done <-chan struct{}) {
+	last := modTimes(paths)
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			current := modTimes(paths)
+			for i := range current {
+				if !current[i].Equal(last[i]) {
+					changed()
+					break
+				}
+			}
+			last = current
+		}
+	}
+}
+
+// modTimes returns the modification time of each of paths, the zero time
+// for paths which do not exist.
+func modTimes(paths []string) []time.Time {
+	times := make([]time.Time, len(paths))
+	for i, path := range paths {
+		if info, err := os.Stat(path); err == nil {
+			times[i] = info.ModTime()
+		}
+	}
+	return times
+}
+
+// YAMLSource is the commands of a spellbook config file, e.g. ~/.spellbook.yml
+type YAMLSource struct {
+	// Path is the absolute path of the config file
+	Path string
+}
+
+// Name is "global" for the config file in the home directory, "project"
+// for the others.
+func (s *YAMLSource) Name() string {
+	if home, err := homedir.Dir(); err == nil && filepath.Dir(s.Path) == home {
+		return "global"
+	}
+	return "project"
+}
+
+func (s *YAMLSource) Load() ([]Command, error) {
+	conf := viper.New()
+	conf.SetConfigFile(s.Path)
+	if err := conf.ReadInConfig(); err != nil {
+		return nil, err
+	}
+	configs, err := unmarshalRawConfigs([]*viper.Viper{conf})
+	if err != nil {
+		return nil, err
+	}
+	for i, command := range configs[0].Commands {
+		if err := command.validate(); err != nil {
+			return nil, &ConfigError{s.Path, fmt.Sprintf("command %d: %v", i+1, err), err}
+		}
+	}
+	return configs[0].Commands, nil
+}
+
+// Watch calls changed each time the config file is saved.
+func (s *YAMLSource) Watch(changed func(), done <-chan struct{}) {
+	watchFiles([]string{s.Path}, changed, done)
+}
+
+// yamlSources returns a source for each config file read
+func yamlSources(conf *Config) []CommandSource {
+	sources := make([]CommandSource, len(conf.Files))
+	for i, path := range conf.Files {
+		sources[i] = &YAMLSource{path}
+	}
+	return sources
+}
+
+// fileSources returns a source, made by newSource, for the first file of
+// names found in the working directory, and one for the first found in the
+// project root. names are the file names a build tool looks for, e.g.
+// GNUmakefile, makefile and Makefile for make.
+func fileSources(names []string, newSource func(path string) CommandSource) []CommandSource {
+	var dirs []string
+	if wd, err := os.Getwd(); err == nil {
+		dirs = append(dirs, wd)
+	}
+	home, _ := homedir.Dir()
+	if dir, found := FindProjectDir("."); found && dir != home {
+		dirs = append(dirs, dir)
+	}
+	var sources []CommandSource
+	seen := make(map[string]bool)
+	for _, dir := range dirs {
+		path, found := findFile(dir, names)
+		if found && !seen[path] {
+			seen[path] = true
+			sources = append(sources, newSource(path))
+		}
+	}
+	return sources
+}
+
+// findFile returns the path of the first file of names found in dir.
+func findFile(dir string, names []string) (string, bool) {
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path, true
+		}
+	}
+	return "", false
+}
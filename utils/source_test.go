@@ -0,0 +1,126 @@
+package utils
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type testSource struct {
+	name     string
+	commands []Command
+	err      error
+}
+
+func (s *testSource) Name() string             { return s.name }
+func (s *testSource) Load() ([]Command, error) { return s.commands, s.err }
+
+func TestLoadCommands(t *testing.T) {
+	sources := []CommandSource{
+		&testSource{name: "a", commands: []Command{{Cmd: "ls"}, {Cmd: "pwd"}}},
+		&testSource{name: "b", err: errors.New("broken")},
+		&testSource{name: "c", commands: []Command{{Cmd: "make"}}},
+	}
+	commands, errs := LoadCommands(sources)
+	if len(commands) != 3 || commands[2].Cmd != "make" {
+		t.Fatalf("expected the commands of the sources loaded, got %v", commands)
+	}
+	if commands[0].SourceName != "a" || commands[2].SourceName != "c" {
+		t.Errorf("expected the commands to name their source, got '%s' and '%s'",
+			commands[0].SourceName, commands[2].SourceName)
+	}
+	if len(errs) != 1 || errs[0].Error() != "b: broken" {
+		t.Errorf("expected the error of source b, got %v", errs)
+	}
+}
+
+func TestYAMLSource(t *testing.T) {
+	dir, err := ioutil.TempDir("", "spellbook")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, ".spellbook.yml")
+	if err := ioutil.WriteFile(path, []byte("cwd: src\ncommands:\n  - cmd: make\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	conf, err := ReadConfig([]string{dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sources := Sources(conf)
+	if len(sources) != 1 || sources[0].Name() != "project" {
+		t.Fatalf("expected a project source, got %v", sources)
+	}
+	commands, err := sources[0].Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(commands) != 1 || commands[0].Source != path || commands[0].Cwd != "src" {
+		t.Errorf("unexpected commands %v", commands)
+	}
+}
+
+func TestWatchFiles(t *testing.T) {
+	defer func(interval time.Duration) { watchInterval = interval }(watchInterval)
+	watchInterval = 10 * time.Millisecond
+
+	dir, err := ioutil.TempDir("", "spellbook")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "Makefile")
+
+	changed := make(chan struct{}, 1)
+	done := make(chan struct{})
+	defer close(done)
+	go watchFiles([]string{path}, func() { changed <- struct{}{} }, done)
+
+	time.Sleep(5 * watchInterval)
+	select {
+	case <-changed:
+		t.Fatal("expected no change before the file is created")
+	default:
+	}
+	if err := ioutil.WriteFile(path, []byte("all:\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-changed:
+	case <-time.After(time.Second):
+		t.Error("expected the file being created to be noticed")
+	}
+}
+
+// writeTemp writes contents to a file called name in a temporary directory,
+// removed once the test is done, returning its path
+func writeTemp(t *testing.T, name, contents string) string {
+	dir, err := ioutil.TempDir("", "spellbook")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// setenv sets the environment variable key to value until the test is done
+func setenv(t *testing.T, key, value string) {
+	old, found := os.LookupEnv(key)
+	t.Cleanup(func() {
+		if found {
+			os.Setenv(key, old)
+		} else {
+			os.Unsetenv(key)
+		}
+	})
+	os.Setenv(key, value)
+}
@@ -0,0 +1,24 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/mitchellh/go-homedir"
+)
+
+// StateDir returns the directory in which spellbook keeps state between runs,
+// creating it if necessary. This is $XDG_STATE_HOME/spellbook, which defaults
+// to ~/.local/state/spellbook.
+func StateDir() (string, error) {
+	dir := os.Getenv("XDG_STATE_HOME")
+	if dir == "" {
+		home, err := homedir.Dir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, ".local", "state")
+	}
+	dir = filepath.Join(dir, "spellbook")
+	return dir, os.MkdirAll(dir, 0700)
+}
@@ -0,0 +1,36 @@
+package utils
+
+// StepResult is the outcome of a single step of a multi-step command
+type StepResult struct {
+	Cmd string
+	Err error
+	// Skipped is true if the step was not run because an earlier step failed
+	Skipped bool
+}
+
+// RunSteps runs each of cmds, in order, using run.
+// Unless keepGoing is true, the remaining steps are skipped once a step fails.
+func RunSteps(cmds []string, keepGoing bool, run func(i int, cmd string) error) []StepResult {
+	results := make([]StepResult, len(cmds))
+	failed := false
+	for i, cmd := range cmds {
+		results[i].Cmd = cmd
+		if failed && !keepGoing {
+			results[i].Skipped = true
+			continue
+		}
+		results[i].Err = run(i, cmd)
+		failed = failed || results[i].Err != nil
+	}
+	return results
+}
+
+// StepsExitCode returns the exit code of the first failed step, or 0 if all steps succeeded.
+func StepsExitCode(results []StepResult) int {
+	for _, res := range results {
+		if res.Err != nil {
+			return ExitCode(res.Err)
+		}
+	}
+	return 0
+}
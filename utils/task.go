@@ -0,0 +1,163 @@
+package utils
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+func init() {
+	RegisterSource("task", taskSources)
+}
+
+// taskfileNames are the names task looks for, in the order it looks for them
+var taskfileNames = []string{
+	"Taskfile.yml", "taskfile.yml", "Taskfile.yaml", "taskfile.yaml",
+	"Taskfile.dist.yml", "taskfile.dist.yml", "Taskfile.dist.yaml", "taskfile.dist.yaml",
+}
+
+// Task is a task of a Taskfile
+type Task struct {
+	Name string
+	Desc string
+	// Vars are the variables the task requires, e.g. `task deploy ENV=prod`
+	Vars []TaskVar
+}
+
+// TaskVar is a variable a task requires, with the values it may take if
+// these are limited
+type TaskVar struct {
+	Name string
+	Enum []string
+}
+
+// taskDef is a task as written in a Taskfile, unless written as only its commands
+type taskDef struct {
+	Desc     string
+	Summary  string
+	Internal bool
+	Requires struct {
+		Vars []interface{}
+	}
+}
+
+// ParseTaskfile returns the tasks of a Taskfile, in order, leaving out
+// internal tasks.
+func ParseTaskfile(r io.Reader) ([]Task, error) {
+	bs, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var taskfile struct {
+		Tasks yaml.MapSlice
+	}
+	if err := yaml.Unmarshal(bs, &taskfile); err != nil {
+		return nil, err
+	}
+	var tasks []Task
+	for _, item := range taskfile.Tasks {
+		task := Task{Name: fmt.Sprint(item.Key)}
+		// tasks written as only their commands, e.g. `build: go build`, have no description
+		if _, ok := item.Value.(yaml.MapSlice); ok {
+			var def taskDef
+			raw, err := yaml.Marshal(item.Value)
+			if err != nil {
+				return nil, err
+			}
+			if err := yaml.Unmarshal(raw, &def); err != nil {
+				return nil, fmt.Errorf("task %s: %v", task.Name, err)
+			}
+			if def.Internal {
+				continue
+			}
+			task.Desc = def.Desc
+			if task.Desc == "" {
+				task.Desc = strings.SplitN(strings.TrimSpace(def.Summary), "\n", 2)[0]
+			}
+			task.Vars = taskVars(def.Requires.Vars)
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, nil
+}
+
+// taskVars returns the variables required, written either as names or as
+// a name with its values, e.g. `{name: ENV, enum: [dev, prod]}`.
+func taskVars(required []interface{}) []TaskVar {
+	var vars []TaskVar
+	for _, v := range required {
+		switch v := v.(type) {
+		case string:
+			vars = append(vars, TaskVar{Name: v})
+		case map[interface{}]interface{}:
+			taskVar := TaskVar{Name: fmt.Sprint(v["name"])}
+			if enum, ok := v["enum"].([]interface{}); ok {
+				for _, value := range enum {
+					taskVar.Enum = append(taskVar.Enum, fmt.Sprint(value))
+				}
+			}
+			vars = append(vars, taskVar)
+		}
+	}
+	return vars
+}
+
+// TaskSource is the tasks of a Taskfile, run as `task <name>` with a
+// variable for each variable the task requires.
+type TaskSource struct {
+	// Path is the absolute path of the Taskfile
+	Path string
+}
+
+func (s *TaskSource) Name() string {
+	return "task"
+}
+
+func (s *TaskSource) Load() ([]Command, error) {
+	f, err := os.Open(s.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	tasks, err := ParseTaskfile(f)
+	if err != nil {
+		return nil, err
+	}
+	commands := make([]Command, len(tasks))
+	for i, task := range tasks {
+		cmd := "task " + EscapeTemplate(task.Name)
+		vars := make(map[string]string)
+		for _, v := range task.Vars {
+			cmd += fmt.Sprintf(" %s=%%(%s)", v.Name, v.Name)
+			if len(v.Enum) != 0 {
+				vars[v.Name] = "one of " + strings.Join(v.Enum, ", ")
+			}
+		}
+		commands[i] = Command{
+			Cmd:    cmd,
+			Desc:   task.Desc,
+			Cwd:    CwdConfigDir,
+			Vars:   vars,
+			Tags:   []string{"task"},
+			Source: s.Path,
+		}
+	}
+	return commands, nil
+}
+
+// Watch calls changed each time the Taskfile is saved.
+func (s *TaskSource) Watch(changed func(), done <-chan struct{}) {
+	watchFiles([]string{s.Path}, changed, done)
+}
+
+// taskSources returns a source for the Taskfile of the working directory and
+// one for the Taskfile of the project root, if these exist.
+func taskSources(conf *Config) []CommandSource {
+	return fileSources(taskfileNames, func(path string) CommandSource {
+		return &TaskSource{path}
+	})
+}
@@ -0,0 +1,62 @@
+package utils
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testTaskfile = `version: '3'
+
+tasks:
+  build: go build ./...
+  test:
+    desc: run the tests
+    cmds:
+      - go test ./...
+  deploy:
+    summary: |
+      deploy the app
+
+      to the environment given
+    requires:
+      vars:
+        - REGION
+        - name: ENV
+          enum: [dev, prod]
+    cmds:
+      - ./deploy.sh
+  setup:
+    internal: true
+    cmds:
+      - go mod download
+`
+
+func TestParseTaskfile(t *testing.T) {
+	tasks, err := ParseTaskfile(strings.NewReader(testTaskfile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Task{
+		{Name: "build"},
+		{Name: "test", Desc: "run the tests"},
+		{Name: "deploy", Desc: "deploy the app", Vars: []TaskVar{{Name: "REGION"}, {Name: "ENV", Enum: []string{"dev", "prod"}}}},
+	}
+	if !reflect.DeepEqual(tasks, expected) {
+		t.Errorf("expected %v, got %v", expected, tasks)
+	}
+}
+
+func TestTaskSourceVariables(t *testing.T) {
+	source := &TaskSource{writeTemp(t, "Taskfile.yml", testTaskfile)}
+	commands, err := source.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(commands) != 3 || commands[2].Cmd != "task deploy REGION=%(REGION) ENV=%(ENV)" {
+		t.Fatalf("expected a variable per required variable, got %v", commands)
+	}
+	if desc := commands[2].Vars["ENV"]; desc != "one of dev, prod" {
+		t.Errorf("expected the values of ENV to be described, got '%s'", desc)
+	}
+}
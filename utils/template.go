@@ -0,0 +1,60 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+)
+
+// VarNames returns the names of the variables in toks, in order of first appearance.
+func VarNames(toks []Token) []string {
+	names := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, tok := range toks {
+		if tok.Type == TokVar && !seen[tok.Lexeme] {
+			seen[tok.Lexeme] = true
+			names = append(names, tok.Lexeme)
+		}
+	}
+	return names
+}
+
+type MissingVarError struct {
+	VarName string
+}
+
+func (e *MissingVarError) Error() string {
+	return fmt.Sprintf("No value given for variable '%s'", e.VarName)
+}
+
+// Expand returns the command described by toks, with each variable replaced by its value.
+func Expand(toks []Token, values map[string]string) (string, error) {
+	var sb strings.Builder
+	for _, tok := range toks {
+		switch tok.Type {
+		case TokLiteral:
+			sb.WriteString(tok.Lexeme)
+		case TokVar:
+			val, found := values[tok.Lexeme]
+			if !found {
+				return "", &MissingVarError{VarName: tok.Lexeme}
+			}
+			sb.WriteString(val)
+		}
+	}
+	return sb.String(), nil
+}
+
+// EscapeTemplate escapes s such that ParseCmd returns it as a single literal.
+func EscapeTemplate(s string) string {
+	return strings.ReplaceAll(s, "%", "%%")
+}
+
+// PromptTemplate returns a template asking for the value of each of names,
+// e.g. `version=%(version) remote=%(remote)`.
+func PromptTemplate(names []string) string {
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = fmt.Sprintf("%s=%%(%s)", name, name)
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,82 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCommand_Template_Steps(t *testing.T) {
+	cmd := Command{Steps: []string{
+		`git tag v%(version)`,
+		`git push %(remote) v%(version)`,
+	}}
+	template, err := cmd.Template()
+	if err != nil {
+		t.Fatal(err)
+	}
+	toks, err := ParseCmd(template)
+	if err != nil {
+		t.Fatal(err)
+	}
+	examineToks(t, toks, []Token{
+		{TokLiteral, `version=`},
+		{TokVar, `version`},
+		{TokLiteral, ` remote=`},
+		{TokVar, `remote`},
+	})
+
+	steps, err := cmd.ExpandSteps(map[string]string{"version": "1.2.0", "remote": "origin"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{`git tag v1.2.0`, `git push origin v1.2.0`}
+	for i := range expected {
+		if steps[i] != expected[i] {
+			t.Errorf("step %d: expected '%s', got '%s'", i, expected[i], steps[i])
+		}
+	}
+}
+
+func TestCommand_Template_StepsWithoutVars(t *testing.T) {
+	cmd := Command{Steps: []string{`make clean`, `echo 100%`}}
+	template, err := cmd.Template()
+	if err != nil {
+		t.Fatal(err)
+	}
+	toks, err := ParseCmd(template)
+	if err != nil {
+		t.Fatal(err)
+	}
+	examineToks(t, toks, []Token{
+		{TokLiteral, `make clean && echo 100%`},
+	})
+}
+
+func TestExpand_MissingVar(t *testing.T) {
+	toks, _ := ParseCmd(`echo %(foo)`)
+	_, err := Expand(toks, map[string]string{})
+	if _, ok := err.(*MissingVarError); !ok {
+		t.Errorf("expected MissingVarError, got %v", err)
+	}
+}
+
+func TestRunSteps(t *testing.T) {
+	fail := errors.New("failed")
+	run := func(i int, cmd string) error {
+		if cmd == "fail" {
+			return fail
+		}
+		return nil
+	}
+	cmds := []string{"ok", "fail", "ok"}
+
+	results := RunSteps(cmds, false, run)
+	if results[1].Err != fail || !results[2].Skipped {
+		t.Errorf("expected steps after failure to be skipped, got %v", results)
+	}
+
+	results = RunSteps(cmds, true, run)
+	if results[1].Err != fail || results[2].Skipped {
+		t.Errorf("expected all steps to be run, got %v", results)
+	}
+}